@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/web"
+)
+
+// assets downloads the front-end libraries of web.Vendored that are missing from the static folder and checks
+// the integrity of all of them. It runs with go generate from the web folder:
+//
+//	go generate ./web
+func main() {
+	dir := flag.String("dir", "static", "Path of the static folder")
+	flag.Parse()
+
+	client := &http.Client{Timeout: time.Minute}
+	failed := false
+	for _, asset := range web.Vendored {
+		if err := fetch(client, *dir, asset); err != nil {
+			log.Println(err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// fetch downloads the asset if it isn't in dir and checks that its content matches its integrity hash
+func fetch(client *http.Client, dir string, asset web.Asset) error {
+	path := filepath.Join(dir, filepath.FromSlash(asset.Path))
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Println("Downloading", asset.URL)
+		content, err = download(client, asset.URL)
+		if err != nil {
+			return err
+		}
+		if err := verify(asset, content); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, content, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+
+	return verify(asset, content)
+}
+
+func download(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading %s: status %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// verify compares the content with the integrity of the asset, or prints the hash to pin when it isn't pinned
+func verify(asset web.Asset, content []byte) error {
+	if asset.Integrity == "" {
+		log.Printf("%s isn't pinned, set its Integrity to %s", asset.Path, web.Hash(content))
+		return nil
+	}
+	return asset.Verify(content)
+}
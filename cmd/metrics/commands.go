@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/api"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+)
+
+// Output formats of the commands
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+	OutputHTML  = "html" // Self-contained dashboard, only for reports
+	OutputPDF   = "pdf"  // Printed dashboard, only for reports
+)
+
+// runTask prints the metrics of a task
+func runTask(args []string) error {
+	flags := flag.NewFlagSet("task", flag.ExitOnError)
+	output := flags.String("output", OutputTable, "Output format: table, json or csv")
+	source := flags.String("source", api.SourceLive, "Read the task from ClickUp (live) or from the snapshots of STORE_PATH (store)")
+	flags.Parse(args)
+
+	if err := checkOutput(*output, OutputTable, OutputJSON, OutputCSV); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: metrics task [flags] <id>")
+	}
+
+	api.Configure()
+	taskMetrics, err := api.GetTaskMetrics(strings.TrimPrefix(flags.Arg(0), "#"), *source)
+	if err != nil {
+		return err
+	}
+
+	if *output == OutputJSON {
+		return writeJSON(os.Stdout, taskMetrics)
+	}
+	return writeExport(os.Stdout, &api.DashboardData{TaskMetrics: []api.TaskMetricsResponse{taskMetrics}}, api.ExportTableTickets, *output, i18n.Default)
+}
+
+// runReport prints the report of the dashboard, the JSON output is the same of GET /api/reports
+func runReport(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	tickets := flags.String("tickets", "", "Comma separated list of ClickUp task IDs or custom IDs")
+	from := flags.String("from", "", "Start date of the merge requests (YYYY-MM-DD)")
+	to := flags.String("to", "", "End date of the merge requests (YYYY-MM-DD)")
+	prefix := flags.String("prefix", "", "Prefix of the merge request titles")
+	dateField := flags.String("date-field", string(mergerequests.DateFieldMerged), "Date of the merge requests filtered by the dates: merged, created or updated")
+	projects := flags.String("projects", "", "Comma separated GitLab project IDs of the DORA metrics")
+	source := flags.String("source", api.SourceLive, "Read the data from ClickUp and GitLab (live) or from the snapshots of STORE_PATH (store)")
+	team := flags.String("team", "", "Team of TEAMS_FILE whose lists, prefix, projects and workflow fill the empty flags")
+	period := flags.String("period", "", "Split the dates into periods for the trends of the json, html and pdf outputs: week or sprint")
+	buckets := flags.String("buckets", "", "Comma separated upper bounds, in days, of the buckets of the cycle time and lead time histograms")
+	table := flags.String("table", api.ExportTableTickets, "Table printed by the table and csv outputs: tickets or merge_requests")
+	output := flags.String("output", OutputTable, "Output format: table, json, csv, html or pdf")
+	lang := flags.String("lang", i18n.Default, "Language of the outputs: es or en")
+	flags.Parse(args)
+
+	if err := checkOutput(*output, OutputTable, OutputJSON, OutputCSV, OutputHTML, OutputPDF); err != nil {
+		return err
+	}
+	if !i18n.Supported(*lang) {
+		return fmt.Errorf("unknown language %s, use es or en", *lang)
+	}
+
+	api.Configure()
+	report, err := api.GetReport(api.DashboardParams{
+		StartDate: *from,
+		EndDate:   *to,
+		Prefix:    *prefix,
+		DateField: mergerequests.ParseDateField(*dateField),
+		Tickets:   *tickets,
+		Projects:  *projects,
+		Source:    *source,
+		Lang:      *lang,
+		Team:      *team,
+		Period:    *period,
+		Buckets:   *buckets,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch *output {
+	case OutputJSON:
+		return writeJSON(os.Stdout, api.NewReportResponse(report))
+	case OutputHTML:
+		return api.RenderStaticReport(os.Stdout, report)
+	case OutputPDF:
+		return api.RenderPDFReport(os.Stdout, report)
+	}
+	return writeExport(os.Stdout, report, *table, *output, *lang)
+}
+
+// runMergeRequests prints the merge requests of a GitLab group
+func runMergeRequests(args []string) error {
+	flags := flag.NewFlagSet("mrs", flag.ExitOnError)
+	group := flags.String("group", "", "GitLab group ID, the default group if empty")
+	from := flags.String("from", "", "Start date (YYYY-MM-DD)")
+	to := flags.String("to", "", "End date (YYYY-MM-DD)")
+	prefix := flags.String("prefix", "", "Prefix of the merge request titles")
+	dateField := flags.String("date-field", string(mergerequests.DateFieldMerged), "Date filtered by the dates: merged, created or updated")
+	output := flags.String("output", OutputTable, "Output format: table, json or csv")
+	flags.Parse(args)
+
+	if err := checkOutput(*output, OutputTable, OutputJSON, OutputCSV); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return fmt.Errorf("--from and --to are required")
+	}
+
+	api.Configure()
+	mrs, err := api.GetMergeRequests(*group, *prefix, *from, *to, mergerequests.ParseDateField(*dateField))
+	if err != nil {
+		return err
+	}
+
+	if *output == OutputJSON {
+		return writeJSON(os.Stdout, mrs)
+	}
+	return writeExport(os.Stdout, &api.DashboardData{MergeRequests: mrs}, api.ExportTableMergeRequests, *output, i18n.Default)
+}
+
+// runIngest saves a snapshot of the ClickUp tickets and the GitLab merge requests in the metrics store
+func runIngest(args []string) error {
+	flags := flag.NewFlagSet("ingest", flag.ExitOnError)
+	dbPath := flags.String("db", "metrics.db", "Path of the SQLite metrics store")
+	tickets := flags.String("tickets", "", "Comma separated list of ClickUp task IDs or custom IDs")
+	from := flags.String("from", "", "Start date of the merge requests (YYYY-MM-DD)")
+	to := flags.String("to", "", "End date of the merge requests (YYYY-MM-DD)")
+	prefix := flags.String("prefix", "", "Prefix of the merge request titles")
+	description := flags.String("description", "", "Description of the snapshot")
+	flags.Parse(args)
+
+	if *tickets == "" && (*from == "" || *to == "") {
+		return fmt.Errorf("nothing to ingest: set --tickets, or --from and --to")
+	}
+
+	store, err := storage.Open(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening metrics store: %v", err)
+	}
+	defer store.Close()
+
+	snapshotID, err := api.Ingest(store, api.IngestParams{
+		Tickets:     strings.Split(*tickets, ","),
+		StartDate:   *from,
+		EndDate:     *to,
+		Prefix:      *prefix,
+		Description: *description,
+	})
+	if err != nil {
+		return fmt.Errorf("error ingesting: %w", err)
+	}
+
+	log.Printf("Snapshot %d saved in %s", snapshotID, *dbPath)
+	return nil
+}
+
+// checkOutput fails before any request is made when the output isn't one of the formats of the command
+func checkOutput(output string, formats ...string) error {
+	for _, format := range formats {
+		if output == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output %s, use %s", output, strings.Join(formats, ", "))
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeExport(w io.Writer, data *api.DashboardData, table string, output string, lang string) error {
+	switch output {
+	case OutputTable:
+		return api.WriteExport(w, data, table, api.ExportFormatTable, lang)
+	case OutputCSV:
+		return api.WriteExport(w, data, table, api.ExportFormatCSV, lang)
+	default:
+		return fmt.Errorf("unknown output %s, use table, json or csv", output)
+	}
+}
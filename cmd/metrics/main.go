@@ -1,14 +1,58 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/lucasvillalbaar/clickup-metrics/pkg/api"
 )
 
+const usage = `Usage: metrics [command] [flags]
+
+Commands:
+  serve    Start the HTTP server (default)
+  task     Print the metrics of a task: metrics task [flags] <id>
+  report   Print the report of tickets and merge requests: metrics report --tickets ... --from ... --to ...
+  mrs      Print the merge requests of a GitLab group: metrics mrs --group ... --from ... --to ...
+  ingest   Save a snapshot in the metrics store: metrics ingest --db metrics.db --tickets ... --from ... --to ...
+
+Run metrics <command> -h to see the flags of a command.
+`
+
 func main() {
+	command := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+	switch command {
+	case "serve":
+		serve()
+	case "task":
+		err = runTask(args)
+	case "report":
+		err = runReport(args)
+	case "mrs":
+		err = runMergeRequests(args)
+	case "ingest":
+		err = runIngest(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
 
+func serve() {
 	router := api.Init()
 	log.Println("Metrics API")
 	err := http.ListenAndServe(":8080", router)
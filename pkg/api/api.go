@@ -2,13 +2,17 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/mux"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/cache"
 	"github.com/lucasvillalbaar/clickup-metrics/pkg/configuration"
 	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
 	"github.com/lucasvillalbaar/clickup-metrics/pkg/data/clickup"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
 	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
 )
 
@@ -17,51 +21,127 @@ type TimeSpentPerStateResponse struct {
 	TimeSpent int    `json:"time_spent"`
 }
 type TaskMetricsResponse struct {
-	Id             string         `json:"id"`
-	CustomId       string         `json:"custom_id"`
-	Name           string         `json:"name"`
-	StartDate      string         `json:"start_date"`
-	DueDate        string         `json:"due_date"`
-	LeadTime       int            `json:"lead_time"`
-	CycleTime      int            `json:"cycle_time"`
-	BlockedTime    int            `json:"blocked_time"`
-	FlowEfficiency float64        `json:"flow_efficiency"`
-	Statuses       []data.History `json:"statuses"`
+	Id             string                       `json:"id"`
+	CustomId       string                       `json:"custom_id"`
+	Name           string                       `json:"name"`
+	StartDate      string                       `json:"start_date"`
+	DueDate        string                       `json:"due_date"`
+	LeadTime       int                          `json:"lead_time"`
+	CycleTime      int                          `json:"cycle_time"`
+	BlockedTime    int                          `json:"blocked_time"`
+	FlowEfficiency float64                      `json:"flow_efficiency"`
+	CodingTime     int                          `json:"coding_time"`
+	ReviewTime     int                          `json:"review_time"`
+	Statuses       []data.History               `json:"statuses"`
+	Status         string                       `json:"status"`            // Current status of the task
+	DoneAt         string                       `json:"done_at,omitempty"` // Date the task was done, empty until it is done
+	InProgress     bool                         `json:"in_progress"`       // Started and not done
+	URL            string                       `json:"url"`               // Page of the task in ClickUp
+	MergeRequests  []mergerequests.MergeRequest `json:"merge_requests,omitempty"`
 }
 
 const (
 	ContextClickUpToken = "clickup_token"
 )
 
-var wf metrics.Workflow
+// taskSource is the data source of the tasks of a request and the workflow their metrics are computed with.
+// It is created for every request, so concurrent requests can read from different sources.
+type taskSource struct {
+	data.Data
+	wf metrics.Workflow
+}
 
-// initialization loads environment variables, initializes the data source, and creates the workflow
-func configureDataSource() {
+// newTaskSource loads the environment variables and creates the source of the tasks. The tasks are read from
+// the metrics store as of snapshotID (the latest snapshot if 0) when source is SourceStore, and from ClickUp otherwise.
+func newTaskSource(source string, snapshotID int64) (*taskSource, error) {
 	err := configuration.LoadEnvironmentVariables()
 	if err != nil {
 		log.Fatal("Error loading .env file:", err)
 	}
 
-	cli := clickup.Init(configuration.GetEnvironmentVariables().ApiKey)
+	// The store doesn't own a workflow, the stored histories use the statuses of ClickUp
+	tasks := &taskSource{wf: createWorkflow(&clickup.Session{})}
+	if source == SourceStore {
+		store, err := storeAsOf(snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		tasks.Data = store
+	} else {
+		tasks.Data = liveDataSource()
+	}
+	return tasks, nil
+}
 
-	data.SetDataSource(cli)
+// refresh discards the copy of the task kept by the data source, if it keeps copies
+func (t *taskSource) refresh(id string) {
+	if r, ok := t.Data.(data.Refresher); ok {
+		r.Refresh(id)
+	}
+}
+
+var (
+	// live is the ClickUp session, shared by the requests and the scheduled collection
+	live     data.Data
+	liveOnce sync.Once
+)
+
+// liveDataSource returns the ClickUp session, wrapped with the cache when it is enabled. It is created the first
+// time it is needed, the environment variables must be already loaded and the cache configured by then.
+func liveDataSource() data.Data {
+	liveOnce.Do(func() {
+		envVars := configuration.GetEnvironmentVariables()
+		cli := clickup.NewSession(envVars.ApiKey, envVars.TeamID)
+		live = cli
+		if cacheStore != nil {
+			live = cache.NewDataSource(cli, cacheStore, cacheTTL)
+		}
+	})
+	return live
+}
 
-	wf = createWorkflow(cli)
+// Configure sets up the templates, the cache, the metrics store and the teams from the environment variables.
+// It is called by Init, and by the commands that use the API functions without serving them.
+func Configure() {
+	configureWeb()
+	configureCache()
+	configureStore()
+	configureTeams()
 }
 
 // Init initializes the API router and sets up the routes
 func Init() *mux.Router {
+	Configure()
+	configureScheduler()
+	configureExporter()
+
 	router := mux.NewRouter()
 
 	router.Use(authInterceptor)
 	router.HandleFunc("/healthcheck", getHealthCheck).Methods("GET")
 	router.HandleFunc("/dashboard", getDashboardHandler).Methods("GET")
+	router.HandleFunc("/dashboard/export", getStaticReportHandler).Methods("GET")
+	router.HandleFunc("/r/{slug}", getSavedReportDashboardHandler).Methods("GET")
+	router.HandleFunc("/saved-reports", getSavedReportsPageHandler).Methods("GET")
+	router.HandleFunc("/teams/compare", getTeamComparisonPageHandler).Methods("GET")
 
+	router.HandleFunc("/metrics", getExporterHandler).Methods("GET")
+	router.HandleFunc("/metrics", getBatchMetricsHandler).Methods("POST")
 	router.HandleFunc("/metrics/{task_id}", getTaskMetricsHandler).Methods("GET")
+	router.HandleFunc("/api/dora", getDoraHandler).Methods("GET")
+	router.HandleFunc("/api/reports", getReportHandler).Methods("GET")
+	router.HandleFunc("/api/snapshots", getSnapshotsHandler).Methods("GET")
+	router.HandleFunc("/api/saved-reports", getSavedReportsHandler).Methods("GET")
+	router.HandleFunc("/api/saved-reports", createSavedReportHandler).Methods("POST")
+	router.HandleFunc("/api/saved-reports/{slug}", getSavedReportHandler).Methods("GET")
+	router.HandleFunc("/api/teams", getTeamsHandler).Methods("GET")
+	router.HandleFunc("/api/teams/compare", getTeamComparisonHandler).Methods("GET")
+	router.HandleFunc("/api/teams/{team_id}", getTeamHandler).Methods("GET")
+	router.HandleFunc("/api/teams/{team_id}/sle", getTeamSLEHandler).Methods("GET")
+	router.HandleFunc("/api/exports/{table}.{format:csv|xlsx}", getExportHandler).Methods("GET")
 
 	// Ruta para servir archivos estáticos (por ejemplo, CSS)
-	staticFileServer := http.FileServer(http.Dir("./static"))
-	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", staticFileServer))
+	router.PathPrefix("/static/").HandlerFunc(getStaticHandler).Methods("GET", "HEAD")
 
 	return router
 }
@@ -89,10 +169,21 @@ func authInterceptor(next http.Handler) http.Handler {
 	})
 }
 
-// getTaskMetrics retrieves the metrics for a specific task
-func getTaskMetrics(taskID string) (TaskMetricsResponse, error) {
-	configureDataSource()
-	taskInfo, err := data.GetTaskByID(taskID)
+// getTaskMetrics retrieves the metrics for a specific task from source, ignoring the cached copy of the task if refresh is set
+func getTaskMetrics(taskID string, source string, snapshotID int64, refresh bool) (TaskMetricsResponse, error) {
+	tasks, err := newTaskSource(source, snapshotID)
+	if err != nil {
+		return TaskMetricsResponse{}, err
+	}
+	if refresh {
+		tasks.refresh(taskID)
+	}
+	return calculateTaskMetrics(tasks, taskID)
+}
+
+// calculateTaskMetrics retrieves the metrics for a specific task from the source. It can be called concurrently.
+func calculateTaskMetrics(source *taskSource, taskID string) (TaskMetricsResponse, error) {
+	taskInfo, err := source.GetTaskByID(taskID)
 	if err != nil {
 		log.Println(err)
 		return TaskMetricsResponse{}, err
@@ -107,14 +198,15 @@ func getTaskMetrics(taskID string) (TaskMetricsResponse, error) {
 		History:   taskInfo.History,
 	})
 
-	metrics.InitMetrics(wf)
-	metrics.SetInfo(tasks)
-
-	metricsPerTask := metrics.CalculateMetrics()
+	metricsPerTask := metrics.Calculate(source.wf, tasks)
 	if len(metricsPerTask) != 0 {
 		result := metricsPerTask[0]
 		startDate, _ := ConvertUnixMillisToString(result.TaskInfo.StartDate)
 		dueDate, _ := ConvertUnixMillisToString(result.TaskInfo.DueDate)
+		doneAt := ""
+		if date, done := source.wf.DoneAt(taskInfo.Status, taskInfo.History); done {
+			doneAt = date.Format("2006-01-02")
+		}
 		return TaskMetricsResponse{
 			Id:             result.TaskInfo.Id,
 			CustomId:       taskInfo.CustomId,
@@ -126,6 +218,10 @@ func getTaskMetrics(taskID string) (TaskMetricsResponse, error) {
 			BlockedTime:    result.Metrics.BlockedTime,
 			FlowEfficiency: result.Metrics.FlowEfficiency,
 			Statuses:       result.TaskInfo.History,
+			Status:         taskInfo.Status,
+			DoneAt:         doneAt,
+			InProgress:     source.wf.IsWorkInProgress(taskInfo.Status),
+			URL:            fmt.Sprintf(clickup.WebTaskURL, result.TaskInfo.Id),
 		}, nil
 	}
 
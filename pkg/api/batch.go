@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	maxBatchSize     = 500 // Maximum number of tasks accepted by POST /metrics
+	batchConcurrency = 10  // Tasks fetched from ClickUp at the same time
+)
+
+type BatchMetricsRequest struct {
+	TaskIDs []string `json:"task_ids"`
+}
+
+type BatchMetricsResult struct {
+	TaskID  string               `json:"task_id"`
+	Metrics *TaskMetricsResponse `json:"metrics,omitempty"`
+	Error   *APIError            `json:"error,omitempty"`
+}
+
+type BatchMetricsSummary struct {
+	Requested         int     `json:"requested"`
+	Succeeded         int     `json:"succeeded"`
+	Failed            int     `json:"failed"`
+	AvgLeadTime       int     `json:"avg_lead_time"`
+	AvgCycleTime      int     `json:"avg_cycle_time"`
+	AvgBlockedTime    int     `json:"avg_blocked_time"`
+	AvgFlowEfficiency float64 `json:"avg_flow_efficiency"`
+}
+
+type BatchMetricsResponse struct {
+	Results []BatchMetricsResult `json:"results"`
+	Summary BatchMetricsSummary  `json:"summary"`
+}
+
+// getBatchMetricsHandler is the handler function for the POST /metrics endpoint.
+// It retrieves the metrics of every task ID (or custom ID) in the request body concurrently and returns them
+// as JSON, along with the errors of the tasks that couldn't be retrieved and a summary of all of them.
+func getBatchMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request BatchMetricsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, newBadRequestError("error.invalid_batch_body"))
+		return
+	}
+
+	taskIDs := normalizeTaskIDs(request.TaskIDs)
+	if len(taskIDs) == 0 {
+		writeError(w, r, newBadRequestError("error.empty_task_ids"))
+		return
+	}
+	if len(taskIDs) > maxBatchSize {
+		writeError(w, r, newBadRequestError("error.too_many_task_ids", maxBatchSize))
+		return
+	}
+
+	snapshotID, err := getSnapshot(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := getBatchMetrics(taskIDs, getSource(r), snapshotID, isRefresh(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// getBatchMetrics retrieves the metrics of the tasks, at most batchConcurrency at the same time.
+// The results keep the order of taskIDs.
+func getBatchMetrics(taskIDs []string, source string, snapshotID int64, refresh bool) (BatchMetricsResponse, error) {
+	tasks, err := newTaskSource(source, snapshotID)
+	if err != nil {
+		return BatchMetricsResponse{}, err
+	}
+	if refresh {
+		for _, taskID := range taskIDs {
+			tasks.refresh(taskID)
+		}
+	}
+	return batchMetrics(tasks, taskIDs), nil
+}
+
+// batchMetrics calculates the metrics of the tasks of the source concurrently, keeping the order of taskIDs
+func batchMetrics(tasks *taskSource, taskIDs []string) BatchMetricsResponse {
+	results := make([]BatchMetricsResult, len(taskIDs))
+	semaphore := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, taskID := range taskIDs {
+		wg.Add(1)
+		go func(i int, taskID string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i] = BatchMetricsResult{TaskID: taskID}
+			taskMetrics, err := calculateTaskMetrics(tasks, taskID)
+			if err != nil {
+				results[i].Error = toAPIError(err)
+				return
+			}
+			results[i].Metrics = &taskMetrics
+		}(i, taskID)
+	}
+	wg.Wait()
+
+	return BatchMetricsResponse{
+		Results: results,
+		Summary: summarizeBatch(results),
+	}
+}
+
+// summarizeBatch counts the successful and failed tasks and averages the metrics of the successful ones
+func summarizeBatch(results []BatchMetricsResult) BatchMetricsSummary {
+	summary := BatchMetricsSummary{
+		Requested: len(results),
+	}
+
+	for _, result := range results {
+		if result.Metrics == nil {
+			summary.Failed++
+			continue
+		}
+		summary.Succeeded++
+		summary.AvgLeadTime += result.Metrics.LeadTime
+		summary.AvgCycleTime += result.Metrics.CycleTime
+		summary.AvgBlockedTime += result.Metrics.BlockedTime
+	}
+
+	if summary.Succeeded == 0 {
+		return summary
+	}
+
+	summary.AvgLeadTime = summary.AvgLeadTime / summary.Succeeded
+	summary.AvgCycleTime = summary.AvgCycleTime / summary.Succeeded
+	summary.AvgBlockedTime = summary.AvgBlockedTime / summary.Succeeded
+	if summary.AvgCycleTime > 0 {
+		summary.AvgFlowEfficiency = (float64(summary.AvgCycleTime) - float64(summary.AvgBlockedTime)) * 100 / float64(summary.AvgCycleTime)
+	}
+
+	return summary
+}
+
+// normalizeTaskIDs removes the "#" prefix and the blanks of the task IDs, skipping the empty and repeated ones
+func normalizeTaskIDs(ids []string) []string {
+	result := []string{}
+	for _, id := range ids {
+		id = strings.ReplaceAll(id, "#", "")
+		id = strings.TrimSpace(id)
+		if id == "" || contains(result, id) {
+			continue
+		}
+		result = append(result, id)
+	}
+	return result
+}
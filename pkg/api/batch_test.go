@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data/clickup"
+)
+
+// fakeData is a data source with the tasks of a map, the IDs missing from it are not found
+type fakeData struct {
+	tasks map[string]data.TaskInfo
+}
+
+func (f fakeData) GetTasksWithFilter(filter data.Filter) ([]data.TaskHeaderData, error) {
+	return nil, nil
+}
+
+func (f fakeData) GetTaskByID(id string) (*data.TaskInfo, error) {
+	if id == "broken" {
+		return nil, fmt.Errorf("%w: status 500", data.ErrUpstream)
+	}
+	task, ok := f.tasks[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", data.ErrNotFound, id)
+	}
+	return &task, nil
+}
+
+func (f fakeData) GetHistoryPerTask(ids []string) *map[string]data.TaskInfo { return nil }
+
+func (f fakeData) GetWorkflow() *data.Workflow { return nil }
+
+func TestBatchMetrics(t *testing.T) {
+	// 2024-01-10, in milliseconds
+	tasks := &taskSource{
+		Data: fakeData{tasks: map[string]data.TaskInfo{
+			"1": newListTask("1", "901", "completed", 2*24*60, "1704880800000"),
+			"2": newListTask("2", "901", "completed", 4*24*60, "1704880800000"),
+			"3": newListTask("3", "901", "in development", 24*60, "1704880800000"),
+		}},
+		wf: createWorkflow(&clickup.Session{}),
+	}
+
+	taskIDs := []string{"3", "missing", "1", "broken", "2"}
+	response := batchMetrics(tasks, taskIDs)
+
+	if len(response.Results) != len(taskIDs) {
+		t.Fatalf("Cantidad de resultados incorrecta, se esperaba %d pero se obtuvo %d", len(taskIDs), len(response.Results))
+	}
+	for i, result := range response.Results {
+		if result.TaskID != taskIDs[i] {
+			t.Errorf("Resultado %d incorrecto, se esperaba %s pero se obtuvo %s", i, taskIDs[i], result.TaskID)
+		}
+	}
+	if response.Results[1].Error == nil || response.Results[1].Error.Code != ErrorCodeNotFound || response.Results[1].Metrics != nil {
+		t.Errorf("Se esperaba un error not_found para la tarea inexistente, se obtuvo %+v", response.Results[1])
+	}
+	if response.Results[3].Error == nil || response.Results[3].Error.Code != ErrorCodeUpstream {
+		t.Errorf("Se esperaba un error upstream_error, se obtuvo %+v", response.Results[3])
+	}
+	if response.Results[2].Metrics == nil || response.Results[2].Metrics.CycleTime != 2 {
+		t.Errorf("Métricas de la tarea 1 incorrectas, se obtuvo %+v", response.Results[2])
+	}
+
+	summary := response.Summary
+	if summary.Requested != 5 || summary.Succeeded != 3 || summary.Failed != 2 {
+		t.Errorf("Resumen incorrecto, se obtuvo %+v", summary)
+	}
+	if summary.AvgCycleTime != (1+2+4)/3 {
+		t.Errorf("Cycle time promedio incorrecto, se esperaba %d pero se obtuvo %d", (1+2+4)/3, summary.AvgCycleTime)
+	}
+}
+
+func TestSummarizeBatchWithoutCycleTime(t *testing.T) {
+	summary := summarizeBatch([]BatchMetricsResult{
+		{TaskID: "1", Metrics: &TaskMetricsResponse{LeadTime: 1, CycleTime: 0}},
+		{TaskID: "2", Error: toAPIError(data.ErrNotFound)},
+	})
+	if summary.Succeeded != 1 || summary.Failed != 1 || summary.AvgFlowEfficiency != 0 || math.IsNaN(summary.AvgFlowEfficiency) {
+		t.Errorf("Resumen incorrecto, se obtuvo %+v", summary)
+	}
+
+	summary = summarizeBatch([]BatchMetricsResult{{TaskID: "1", Error: toAPIError(data.ErrNotFound)}})
+	if summary.Failed != 1 || summary.AvgCycleTime != 0 || summary.AvgFlowEfficiency != 0 {
+		t.Errorf("Resumen sin tareas incorrecto, se obtuvo %+v", summary)
+	}
+}
+
+func TestNormalizeTaskIDs(t *testing.T) {
+	ids := normalizeTaskIDs([]string{" #85aa", "85aa", "", " ", "PRJ-1 ", "#PRJ-1"})
+	if !reflect.DeepEqual(ids, []string{"85aa", "PRJ-1"}) {
+		t.Errorf("IDs incorrectos, se obtuvo %v", ids)
+	}
+}
+
+func TestBatchMetricsSizeLimit(t *testing.T) {
+	ids := make([]string, maxBatchSize+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf(`"%d"`, i)
+	}
+
+	tests := map[string]int{
+		`{"task_ids": ["#", " "]}`: http.StatusBadRequest,
+		`{"task_ids": `:            http.StatusBadRequest,
+	}
+	for body, status := range tests {
+		recorder := httptest.NewRecorder()
+		getBatchMetricsHandler(recorder, httptest.NewRequest("POST", "/metrics", strings.NewReader(body)))
+		if recorder.Code != status {
+			t.Errorf("Código incorrecto, se esperaba %d pero se obtuvo %d: %s", status, recorder.Code, recorder.Body)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	getBatchMetricsHandler(recorder, httptest.NewRequest("POST", "/metrics", strings.NewReader(`{"task_ids": [`+strings.Join(ids, ",")+`]}`)))
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), fmt.Sprint(maxBatchSize)) {
+		t.Errorf("Se esperaba un error por el límite de %d tareas: %s", maxBatchSize, recorder.Body)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/cache"
+)
+
+const (
+	CacheMemory = "memory"
+	CacheDisk   = "disk"
+	CacheNone   = "none"
+)
+
+// cacheStore keeps the responses of ClickUp and GitLab between requests, it is nil when the cache is disabled
+var cacheStore cache.Store
+var cacheTTL = cache.DefaultTTL
+
+// configureCache creates the cache store from the CACHE (memory, disk or none), CACHE_DIR,
+// CACHE_TTL_SHORT and CACHE_TTL_LONG environment variables
+func configureCache() {
+	cacheTTL.Short = getDurationEnv("CACHE_TTL_SHORT", cache.DefaultTTL.Short)
+	cacheTTL.Long = getDurationEnv("CACHE_TTL_LONG", cache.DefaultTTL.Long)
+
+	switch os.Getenv("CACHE") {
+	case CacheNone:
+		cacheStore = nil
+	case CacheDisk:
+		dir := os.Getenv("CACHE_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "clickup-metrics-cache")
+		}
+		store, err := cache.NewDisk(dir)
+		if err != nil {
+			log.Fatal("Error creating cache directory:", err)
+		}
+		log.Println("Caching responses in", dir)
+		cacheStore = store
+	default:
+		cacheStore = cache.NewMemory()
+	}
+}
+
+// getDurationEnv parses the environment variable as a duration (e.g. 10m, 24h), returning defaultValue if it is not set
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Error: Environment variable %s is not a valid duration: %v", key, err)
+	}
+	return duration
+}
+
+// isRefresh reports whether the request asks to ignore the cached responses with ?refresh=true
+func isRefresh(r *http.Request) bool {
+	return r.URL.Query().Get("refresh") == "true"
+}
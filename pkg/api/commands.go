@@ -0,0 +1,32 @@
+package api
+
+import (
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+)
+
+// The functions of this file expose the data of the endpoints to the command line, call Configure before them.
+
+// GetTaskMetrics retrieves the metrics of a task from source (SourceLive or SourceStore)
+func GetTaskMetrics(taskID string, source string) (TaskMetricsResponse, error) {
+	return getTaskMetrics(taskID, source, 0, false)
+}
+
+// GetReport builds the same report the dashboard renders
+func GetReport(params DashboardParams) (*DashboardData, error) {
+	if params.Source == "" {
+		params.Source = SourceLive
+	}
+	return getDashboardData(params)
+}
+
+// GetMergeRequests retrieves the merge requests of the GitLab group (the default group if empty) whose title contains prefix
+func GetMergeRequests(groupID string, prefix string, startDate string, endDate string, dateField mergerequests.DateField) ([]mergerequests.MergeRequest, error) {
+	cli, err := newGitlabClient(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if groupID != "" {
+		cli.GroupID = groupID
+	}
+	return cli.GetMergeRequestsBetween(startDate, endDate, dateField)
+}
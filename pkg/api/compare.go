@@ -0,0 +1,254 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+// TeamComparison are the flow metrics of a team between the dates of the comparison.
+// The cycle time and flow efficiency are the ones of the tasks done between the dates.
+type TeamComparison struct {
+	Team              string    `json:"team"`
+	Name              string    `json:"name"`
+	Tasks             int       `json:"tasks"`      // Tasks of the lists of the team updated since the start date
+	Throughput        int       `json:"throughput"` // Tasks done between the dates
+	CycleTimeP50      int       `json:"cycle_time_p50"`
+	CycleTimeP85      int       `json:"cycle_time_p85"`
+	CycleTimeP95      int       `json:"cycle_time_p95"`
+	AvgFlowEfficiency float64   `json:"avg_flow_efficiency"`
+	WIP               int       `json:"wip"`            // Tasks started and not done
+	MergeRequests     int       `json:"merge_requests"` // Merge requests merged between the dates
+	TimeToMergeP50    int       `json:"time_to_merge_p50"`
+	TimeToMergeP85    int       `json:"time_to_merge_p85"`
+	Error             *APIError `json:"error,omitempty"`
+}
+
+// TeamComparisonResponse is the comparison of the teams over the same dates
+type TeamComparisonResponse struct {
+	StartDate string           `json:"start_date"`
+	EndDate   string           `json:"end_date"`
+	Teams     []TeamComparison `json:"teams"`
+}
+
+// ComparisonPage is the data of the page that compares the teams
+type ComparisonPage struct {
+	StartDate       string
+	EndDate         string
+	Source          string
+	Comparisons     []TeamComparison
+	Selected        map[string]bool // Teams of the comparison
+	Teams           []teams.Team    // Every configured team
+	CycleTimeData   ChartData
+	ThroughputData  ChartData
+	TimeToMergeData ChartData
+	Error           string
+	Lang            string
+}
+
+// compareTeams computes the metrics of every team between the dates, with the data of source.
+// The errors of a team are reported in its comparison, unless they affect every team, like an expired API key.
+func compareTeams(teamIDs []string, startDate string, endDate string, source string, snapshotID int64, refresh bool) (TeamComparisonResponse, error) {
+	response := TeamComparisonResponse{StartDate: startDate, EndDate: endDate, Teams: []TeamComparison{}}
+	for _, teamID := range teamIDs {
+		comparison, err := compareTeam(teamID, startDate, endDate, source, snapshotID, refresh)
+		if errors.Is(err, data.ErrUnauthorized) || errors.Is(err, data.ErrRateLimited) || errors.Is(err, errStoreNotConfigured) {
+			return response, err
+		}
+		if err != nil {
+			comparison.Error = toAPIError(err)
+		}
+		response.Teams = append(response.Teams, comparison)
+	}
+	return response, nil
+}
+
+// compareTeam computes the metrics of the tasks of the lists of the team and of its merge requests
+func compareTeam(teamID string, startDate string, endDate string, source string, snapshotID int64, refresh bool) (TeamComparison, error) {
+	comparison := TeamComparison{Team: teamID, Name: teamID}
+	params, err := applyTeam(DashboardParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+		DateField: mergerequests.DateFieldMerged,
+		Source:    source,
+		Snapshot:  snapshotID,
+		Refresh:   refresh,
+		Team:      teamID,
+	})
+	if err != nil {
+		return comparison, err
+	}
+	comparison.Name = params.team.Name
+
+	result := &DashboardData{}
+	if err := getClickUpData(result, params); err != nil {
+		return comparison, err
+	}
+	summarizeTeamTasks(&comparison, result.TaskMetrics, startDate, endDate)
+
+	// Without a prefix every merge request of the group would be counted for the team
+	if params.Prefix != "" {
+		if err := getGitLabData(result, startDate, endDate, params.Prefix, params.DateField, source, snapshotID, refresh); err != nil {
+			return comparison, err
+		}
+		summarizeTeamMergeRequests(&comparison, result.MergeRequests)
+	}
+	return comparison, nil
+}
+
+// summarizeTeamTasks fills the throughput, the cycle time percentiles, the flow efficiency and the WIP of the team
+func summarizeTeamTasks(comparison *TeamComparison, tasks []TaskMetricsResponse, startDate string, endDate string) {
+	comparison.Tasks = len(tasks)
+	cycleTimes := []int{}
+	for _, task := range tasks {
+		if task.InProgress {
+			comparison.WIP++
+		}
+		if task.DoneAt == "" || task.DoneAt < startDate || (endDate != "" && task.DoneAt > endDate) {
+			continue
+		}
+		cycleTimes = append(cycleTimes, task.CycleTime)
+		comparison.AvgFlowEfficiency += task.FlowEfficiency
+	}
+
+	comparison.Throughput = len(cycleTimes)
+	if comparison.Throughput > 0 {
+		comparison.AvgFlowEfficiency = comparison.AvgFlowEfficiency / float64(comparison.Throughput)
+	}
+	comparison.CycleTimeP50 = metrics.Percentile(cycleTimes, 50)
+	comparison.CycleTimeP85 = metrics.Percentile(cycleTimes, 85)
+	comparison.CycleTimeP95 = metrics.Percentile(cycleTimes, 95)
+}
+
+// summarizeTeamMergeRequests fills the count and the time to merge percentiles of the merged merge requests
+func summarizeTeamMergeRequests(comparison *TeamComparison, mrs []mergerequests.MergeRequest) {
+	timesToMerge := []int{}
+	for _, mr := range mrs {
+		if mr.MergedAt != "" && mr.TimeToMerge >= 0 {
+			timesToMerge = append(timesToMerge, mr.TimeToMerge)
+		}
+	}
+	comparison.MergeRequests = len(timesToMerge)
+	comparison.TimeToMergeP50 = metrics.Percentile(timesToMerge, 50)
+	comparison.TimeToMergeP85 = metrics.Percentile(timesToMerge, 85)
+}
+
+// parseComparisonTeams returns the teams of the teams param, or every configured team without it
+func parseComparisonTeams(r *http.Request) []string {
+	teamIDs := splitList(r.URL.Query().Get("teams"))
+	if len(teamIDs) > 0 {
+		return teamIDs
+	}
+	for _, team := range teamRegistry.Teams() {
+		teamIDs = append(teamIDs, team.ID)
+	}
+	return teamIDs
+}
+
+// getTeamComparisonHandler is the handler function for the GET /api/teams/compare endpoint.
+// It compares the teams of the teams param, or every team, between start_date and end_date.
+func getTeamComparisonHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+	if startDate == "" || endDate == "" {
+		writeError(w, r, newBadRequestError("error.dates_required"))
+		return
+	}
+	snapshotID, err := getSnapshot(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := compareTeams(parseComparisonTeams(r), startDate, endDate, getSource(r), snapshotID, isRefresh(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// getTeamComparisonPageHandler is the handler function for the GET /teams/compare page.
+// Without dates it only renders the filters, the last 30 days are selected.
+func getTeamComparisonPageHandler(w http.ResponseWriter, r *http.Request) {
+	lang := getLanguage(r)
+	tmpl, err := getDashboardTemplates(lang)
+	if err != nil {
+		log.Println("Error when reading template: ", err)
+		http.Error(w, i18n.T(lang, "error.internal"), http.StatusInternalServerError)
+		return
+	}
+
+	page := ComparisonPage{
+		StartDate: r.URL.Query().Get("start_date"),
+		EndDate:   r.URL.Query().Get("end_date"),
+		Source:    getSource(r),
+		Selected:  map[string]bool{},
+		Teams:     teamRegistry.Teams(),
+		Lang:      lang,
+	}
+	teamIDs := parseComparisonTeams(r)
+	for _, teamID := range teamIDs {
+		page.Selected[teamID] = true
+	}
+
+	if page.StartDate != "" && page.EndDate != "" {
+		err := renderComparison(&page, teamIDs, r, lang)
+		if err != nil {
+			apiErr := toAPIError(err).localize(lang)
+			page.Error = apiErr.Message
+			w.WriteHeader(apiErr.Status)
+		}
+	} else {
+		page.EndDate = time.Now().Format("2006-01-02")
+		page.StartDate = time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "team_comparison", page); err != nil {
+		log.Println("Error when executing template: ", err)
+		http.Error(w, i18n.T(lang, "error.internal"), http.StatusInternalServerError)
+	}
+}
+
+// renderComparison fills the comparison of the page and its charts, with the errors of the teams in lang
+func renderComparison(page *ComparisonPage, teamIDs []string, r *http.Request, lang string) error {
+	snapshotID, err := getSnapshot(r)
+	if err != nil {
+		return err
+	}
+	response, err := compareTeams(teamIDs, page.StartDate, page.EndDate, page.Source, snapshotID, isRefresh(r))
+	if err != nil {
+		return err
+	}
+	for i := range response.Teams {
+		if response.Teams[i].Error != nil {
+			response.Teams[i].Error = response.Teams[i].Error.localize(lang)
+		}
+	}
+	page.Comparisons = response.Teams
+
+	page.CycleTimeData = ChartData{ChartID: "cycle-time-p85-chart", ChartLabel: "Cycle Time P85"}
+	page.ThroughputData = ChartData{ChartID: "throughput-chart", ChartLabel: "Throughput"}
+	page.TimeToMergeData = ChartData{ChartID: "time-to-merge-p85-chart", ChartLabel: "Time To Merge P85"}
+	for _, comparison := range response.Teams {
+		for _, chart := range []*ChartData{&page.CycleTimeData, &page.ThroughputData, &page.TimeToMergeData} {
+			chart.Labels = append(chart.Labels, comparison.Name)
+		}
+		page.CycleTimeData.Data = append(page.CycleTimeData.Data, comparison.CycleTimeP85)
+		page.ThroughputData.Data = append(page.ThroughputData.Data, comparison.Throughput)
+		page.TimeToMergeData.Data = append(page.TimeToMergeData.Data, comparison.TimeToMergeP85)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+func TestCompareTeams(t *testing.T) {
+	t.Setenv("API_KEY", "test")
+	store, err := storage.Open(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricsStore = store
+	registry, err := teams.Parse(strings.NewReader(`[{"id": "core", "name": "Core", "clickup_lists": ["901"]}, {"id": "prga", "clickup_lists": ["902"]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamRegistry = registry
+	defer func() { metricsStore, teamRegistry = nil, nil }()
+
+	// 2024-01-10 and 2024-01-20, in milliseconds
+	tasks := []data.TaskInfo{
+		newListTask("1", "901", "completed", 3*24*60, "1704880800000"),
+		newListTask("2", "901", "completed", 5*24*60, "1705744800000"),
+		newListTask("3", "901", "in development", 24*60, "1705744800000"),
+		newListTask("4", "902", "completed", 24*60, "1705744800000"),
+	}
+	if _, err := store.SaveSnapshot(storage.Snapshot{Tasks: tasks}); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := compareTeams([]string{"core", "prga", "missing"}, "2024-01-01", "2024-01-31", SourceStore, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Teams) != 3 {
+		t.Fatalf("Cantidad de equipos incorrecta, se esperaba %d pero se obtuvo %d", 3, len(response.Teams))
+	}
+
+	core := response.Teams[0]
+	if core.Name != "Core" || core.Tasks != 3 || core.Throughput != 2 || core.WIP != 1 {
+		t.Errorf("Comparación de Core incorrecta, se obtuvo %+v", core)
+	}
+	if core.CycleTimeP50 != 3 || core.CycleTimeP95 != 5 {
+		t.Errorf("Percentiles de cycle time incorrectos, se obtuvo %+v", core)
+	}
+	if response.Teams[1].Throughput != 1 {
+		t.Errorf("Throughput de prga incorrecto, se esperaba %d pero se obtuvo %d", 1, response.Teams[1].Throughput)
+	}
+	if response.Teams[2].Error == nil || response.Teams[2].Error.Code != ErrorCodeNotFound {
+		t.Errorf("Se esperaba un error not_found para el equipo desconocido, se obtuvo %+v", response.Teams[2].Error)
+	}
+}
+
+// newListTask returns a task of the list that spent minutes in development and then entered status at since
+func newListTask(id string, listID string, status string, minutes int, since string) data.TaskInfo {
+	return data.TaskInfo{
+		TaskHeaderData: data.TaskHeaderData{Id: id, CustomId: "PRJ-" + id, Name: "Task " + id, Status: status, ListID: listID},
+		History: []data.History{
+			{Status: "in development", Time: minutes, Since: "1704067200000"},
+			{Status: status, Time: 0, Since: since},
+		},
+	}
+}
+
+func TestSummarizeTeamMergeRequests(t *testing.T) {
+	comparison := TeamComparison{}
+	summarizeTeamMergeRequests(&comparison, []mergerequests.MergeRequest{
+		{MergedAt: "2024-01-02", TimeToMerge: 1},
+		{MergedAt: "2024-01-03", TimeToMerge: 4},
+		{MergedAt: "", TimeToMerge: 0},
+	})
+	if comparison.MergeRequests != 2 || comparison.TimeToMergeP50 != 1 || comparison.TimeToMergeP85 != 4 {
+		t.Errorf("Resumen de merge requests incorrecto, se obtuvo %+v", comparison)
+	}
+}
+
+func TestTeamComparisonTemplate(t *testing.T) {
+	tmpl, err := getDashboardTemplates(i18n.English)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := ComparisonPage{
+		Teams:       []teams.Team{{ID: "core", Name: "Core"}},
+		Selected:    map[string]bool{"core": true},
+		Comparisons: []TeamComparison{{Team: "core", Name: "Core", Throughput: 7}},
+		Lang:        i18n.English,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "team_comparison", page); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<h1>Compare teams</h1>") || !strings.Contains(buf.String(), `value="core" checked`) {
+		t.Errorf("La comparación no incluye el equipo seleccionado")
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/dora"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+)
+
+type DoraResponse struct {
+	StartDate   string            `json:"start_date"`
+	EndDate     string            `json:"end_date"`
+	Projects    []int             `json:"projects"`
+	Metrics     dora.Metrics      `json:"metrics"`
+	Deployments []dora.Deployment `json:"deployments"`
+}
+
+// getDoraHandler is the handler function for the GET /api/dora endpoint.
+// It computes the DORA metrics of the GitLab projects between start_date and end_date and returns them as JSON.
+func getDoraHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+	if startDate == "" || endDate == "" {
+		writeError(w, r, newBadRequestError("error.dates_required"))
+		return
+	}
+
+	projectIDs, err := parseProjectIDs(r.URL.Query().Get("projects"))
+	if err != nil || len(projectIDs) == 0 {
+		writeError(w, r, newBadRequestError("error.invalid_projects"))
+		return
+	}
+
+	deployments, metrics, err := getDoraMetrics(startDate, endDate, projectIDs, isRefresh(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(DoraResponse{
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Projects:    projectIDs,
+		Metrics:     metrics,
+		Deployments: deployments,
+	})
+	if err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// getDoraData fills the DORA metrics of the dashboard when the date range and the GitLab projects are known
+func getDoraData(result *DashboardData, startDate string, endDate string, projects string, refresh bool) error {
+	if startDate == "" || endDate == "" {
+		return nil
+	}
+
+	projectIDs, err := parseProjectIDs(projects)
+	if err != nil {
+		return newBadRequestError("error.invalid_projects")
+	}
+	if len(projectIDs) == 0 {
+		return nil
+	}
+
+	deployments, metrics, err := getDoraMetrics(startDate, endDate, projectIDs, refresh)
+	if err != nil {
+		return err
+	}
+
+	result.Dora = &metrics
+	result.Deployments = deployments
+	return nil
+}
+
+// getDoraMetrics retrieves the production deployments of the projects and computes their DORA metrics
+func getDoraMetrics(startDate string, endDate string, projectIDs []int, refresh bool) ([]dora.Deployment, dora.Metrics, error) {
+	cli, err := newGitlabClient("")
+	if err != nil {
+		return nil, dora.Metrics{}, err
+	}
+	cli.Refresh = refresh
+
+	environment := os.Getenv("GITLAB_PRODUCTION_ENVIRONMENT")
+	if environment == "" {
+		environment = mergerequests.DefaultProductionEnvironment
+	}
+
+	deployments, err := dora.Collect(cli, projectIDs, environment, startDate, endDate)
+	if err != nil {
+		return nil, dora.Metrics{}, err
+	}
+
+	return deployments, dora.Calculate(deployments, daysBetween(startDate, endDate)), nil
+}
+
+// parseProjectIDs converts a comma separated list of GitLab project IDs, falling back to the GITLAB_PROJECTS environment variable
+func parseProjectIDs(projects string) ([]int, error) {
+	if projects == "" {
+		projects = os.Getenv("GITLAB_PROJECTS")
+	}
+
+	projectIDs := []int{}
+	for _, project := range strings.Split(projects, ",") {
+		project = strings.TrimSpace(project)
+		if project == "" {
+			continue
+		}
+		id, err := strconv.Atoi(project)
+		if err != nil {
+			return nil, err
+		}
+		projectIDs = append(projectIDs, id)
+	}
+
+	return projectIDs, nil
+}
+
+// daysBetween returns the number of days between two dates (YYYY-MM-DD), both inclusive
+func daysBetween(startDate string, endDate string) int {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return 0
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return 0
+	}
+	return int(end.Sub(start).Hours()/24) + 1
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+// Error codes of the JSON error envelope
+const (
+	ErrorCodeBadRequest     = "bad_request"
+	ErrorCodeUnauthorized   = "unauthorized"
+	ErrorCodeForbidden      = "forbidden"
+	ErrorCodeNotFound       = "not_found"
+	ErrorCodeRateLimited    = "rate_limited"
+	ErrorCodeUpstream       = "upstream_error"
+	ErrorCodeInternal       = "internal_error"
+	ErrorCodeNotImplemented = "not_implemented"
+	ErrorCodeNotConfigured  = "not_configured"
+)
+
+// APIError is an error with the HTTP status and the code it is reported with
+type APIError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// messageID and args translate the message to the language of the request, messages without ID are not translated
+	messageID string
+	args      []interface{}
+}
+
+// newAPIError creates an error whose message is the i18n message, in the default language until it is localized
+func newAPIError(status int, code string, messageID string, args ...interface{}) *APIError {
+	return &APIError{
+		Status:    status,
+		Code:      code,
+		Message:   i18n.T(i18n.Default, messageID, args...),
+		messageID: messageID,
+		args:      args,
+	}
+}
+
+// localize returns the error with its message in lang
+func (e *APIError) localize(lang string) *APIError {
+	if e.messageID == "" {
+		return e
+	}
+	localized := *e
+	localized.Message = i18n.T(lang, e.messageID, e.args...)
+	return &localized
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// ErrorResponse is the envelope of every JSON error returned by the API
+type ErrorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// newBadRequestError creates the error returned when the request params or body are not valid
+func newBadRequestError(messageID string, args ...interface{}) *APIError {
+	return newAPIError(http.StatusBadRequest, ErrorCodeBadRequest, messageID, args...)
+}
+
+// newNotConfiguredError creates the error returned when the service lacks the configuration a request needs
+func newNotConfiguredError(messageID string) *APIError {
+	return newAPIError(http.StatusServiceUnavailable, ErrorCodeNotConfigured, messageID)
+}
+
+// toAPIError maps the errors of the data sources and the GitLab client to their HTTP status and code
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.Is(err, data.ErrUnauthorized):
+		return newAPIError(http.StatusUnauthorized, ErrorCodeUnauthorized, "error.clickup_unauthorized")
+	case errors.Is(err, mergerequests.ErrUnauthorized):
+		return newAPIError(http.StatusUnauthorized, ErrorCodeUnauthorized, "error.gitlab_unauthorized")
+	case errors.Is(err, mergerequests.ErrForbidden):
+		return newAPIError(http.StatusForbidden, ErrorCodeForbidden, "error.gitlab_forbidden")
+	case errors.Is(err, data.ErrNotFound), errors.Is(err, mergerequests.ErrNotFound), errors.Is(err, storage.ErrSnapshotNotFound),
+		errors.Is(err, storage.ErrReportNotFound), errors.Is(err, teams.ErrTeamNotFound):
+		// The details of the data source are not translated
+		return &APIError{Status: http.StatusNotFound, Code: ErrorCodeNotFound, Message: err.Error()}
+	case errors.Is(err, data.ErrRateLimited), errors.Is(err, mergerequests.ErrRateLimited):
+		return newAPIError(http.StatusTooManyRequests, ErrorCodeRateLimited, "error.rate_limited")
+	case errors.Is(err, data.ErrUpstream), errors.Is(err, mergerequests.ErrUpstream):
+		return &APIError{Status: http.StatusBadGateway, Code: ErrorCodeUpstream, Message: err.Error()}
+	default:
+		return newAPIError(http.StatusInternalServerError, ErrorCodeInternal, "error.internal")
+	}
+}
+
+// writeError writes the JSON error envelope with the HTTP status that corresponds to err,
+// with the message in the language of the request
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := toAPIError(err).localize(getLanguage(r))
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Println(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Status)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Error: apiErr}); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+)
+
+func TestToAPIError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{data.ErrUnauthorized, http.StatusUnauthorized, ErrorCodeUnauthorized},
+		{fmt.Errorf("%w: 85zt8cyjd", data.ErrNotFound), http.StatusNotFound, ErrorCodeNotFound},
+		{data.ErrRateLimited, http.StatusTooManyRequests, ErrorCodeRateLimited},
+		{fmt.Errorf("%w: ClickUp responded 500", data.ErrUpstream), http.StatusBadGateway, ErrorCodeUpstream},
+		{fmt.Errorf("%w: 401", mergerequests.ErrUnauthorized), http.StatusUnauthorized, ErrorCodeUnauthorized},
+		{fmt.Errorf("%w: 403", mergerequests.ErrForbidden), http.StatusForbidden, ErrorCodeForbidden},
+		{errGitlabNotConfigured, http.StatusServiceUnavailable, ErrorCodeNotConfigured},
+		{newBadRequestError("start_date is required"), http.StatusBadRequest, ErrorCodeBadRequest},
+		{errors.New("unexpected"), http.StatusInternalServerError, ErrorCodeInternal},
+	}
+
+	for _, c := range cases {
+		apiErr := toAPIError(c.err)
+		if apiErr.Status != c.status || apiErr.Code != c.code {
+			t.Errorf("toAPIError(%v) incorrecto, se esperaba %d %s pero se obtuvo %d %s", c.err, c.status, c.code, apiErr.Status, apiErr.Code)
+		}
+	}
+}
+
+func TestWriteErrorLocalizesTheMessage(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/reports", nil)
+	r.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	recorder := httptest.NewRecorder()
+
+	writeError(recorder, r, newBadRequestError("error.too_many_task_ids", 500))
+
+	var response ErrorResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error.Message != "task_ids can't have more than 500 tasks" {
+		t.Errorf("Mensaje incorrecto, se obtuvo %q", response.Error.Message)
+	}
+	if newBadRequestError("error.too_many_task_ids", 500).Error() != "task_ids no puede tener más de 500 tareas" {
+		t.Errorf("El mensaje por defecto debe estar en español")
+	}
+}
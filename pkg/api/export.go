@@ -0,0 +1,254 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gorilla/mux"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// Tables and formats of GET /api/exports/{table}.{format}
+const (
+	ExportTableTickets       = "tickets"
+	ExportTableMergeRequests = "merge_requests"
+	ExportFormatCSV          = "csv"
+	ExportFormatXLSX         = "xlsx"
+	ExportFormatTable        = "table" // Aligned text, for terminals
+)
+
+// getExportHandler is the handler function for the GET /api/exports/{table}.{format} endpoint.
+// It returns the tickets or merge requests table of the dashboard, with the same filters, as a CSV or Excel file.
+func getExportHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	table := vars["table"]
+	format := vars["format"]
+
+	if table != ExportTableTickets && table != ExportTableMergeRequests {
+		writeError(w, r, newBadRequestError("error.invalid_table"))
+		return
+	}
+
+	params, err := parseDashboardParams(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	data, err := getExportData(params, table)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	filename := table
+	if params.StartDate != "" && params.EndDate != "" {
+		filename = fmt.Sprintf("%s_%s_%s", table, params.StartDate, params.EndDate)
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+
+	if format == ExportFormatXLSX {
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	}
+
+	if err := WriteExport(w, data, table, format, getLanguage(r)); err != nil {
+		log.Println("Error writing export:", err)
+	}
+}
+
+// getExportData retrieves only the data of the table. The tickets are linked to the merge requests when the dates
+// are set, but a GitLab error doesn't prevent exporting them, their merge requests columns are left empty instead.
+func getExportData(params DashboardParams, table string) (*DashboardData, error) {
+	result := &DashboardData{
+		StartDate: params.StartDate,
+		EndDate:   params.EndDate,
+		Tickets:   params.Tickets,
+		Prefix:    params.Prefix,
+		DateField: string(params.DateField),
+		Source:    params.Source,
+		Team:      params.Team,
+	}
+
+	params, err := applyTeam(params)
+	if err != nil {
+		return result, err
+	}
+	result.Prefix = params.Prefix
+
+	gitlabErr := getGitLabData(result, params.StartDate, params.EndDate, params.Prefix, params.DateField, params.Source, params.Snapshot, params.Refresh)
+	if table == ExportTableMergeRequests {
+		linkMergeRequests(result)
+		return result, gitlabErr
+	}
+
+	if gitlabErr != nil {
+		log.Println("Exporting the tickets without their merge requests:", gitlabErr)
+		result.MergeRequests = nil
+	}
+	if err := getClickUpData(result, params); err != nil {
+		return result, err
+	}
+	linkMergeRequests(result)
+	return result, nil
+}
+
+// WriteExport writes the tickets or merge requests table of the report as csv, xlsx or an aligned text table,
+// with the header in the given language
+func WriteExport(w io.Writer, data *DashboardData, table string, format string, lang string) error {
+	var rows [][]interface{}
+	switch table {
+	case ExportTableTickets:
+		rows = ticketRows(data, lang)
+	case ExportTableMergeRequests:
+		rows = mergeRequestRows(data, lang)
+	default:
+		return fmt.Errorf("unknown table %s", table)
+	}
+
+	switch format {
+	case ExportFormatXLSX:
+		return writeXLSX(w, table, rows)
+	case ExportFormatTable:
+		return writeTable(w, rows)
+	default:
+		return writeCSV(w, rows)
+	}
+}
+
+// ticketRows returns the header and the rows of the tickets table, with the minutes spent in every status
+// the tickets went through as extra columns
+func ticketRows(data *DashboardData, lang string) [][]interface{} {
+	statuses := []string{}
+	for _, task := range data.TaskMetrics {
+		for _, history := range task.Statuses {
+			if !contains(statuses, history.Status) {
+				statuses = append(statuses, history.Status)
+			}
+		}
+	}
+
+	header := []interface{}{"ID", "Custom ID", i18n.T(lang, "tickets.name"), i18n.T(lang, "tickets.start"),
+		i18n.T(lang, "tickets.end"), "Lead Time", "Cycle Time", "Blocked Time",
+		"Flow Efficiency", "Coding Time", "Review Time", "MRs"}
+	for _, status := range statuses {
+		header = append(header, status+" (min)")
+	}
+
+	rows := [][]interface{}{header}
+	for _, task := range data.TaskMetrics {
+		mrs := []string{}
+		for _, mr := range task.MergeRequests {
+			mrs = append(mrs, "!"+strconv.Itoa(mr.IID))
+		}
+
+		row := []interface{}{task.Id, task.CustomId, task.Name, task.StartDate, task.DueDate, task.LeadTime, task.CycleTime,
+			task.BlockedTime, round(task.FlowEfficiency), task.CodingTime, task.ReviewTime, strings.Join(mrs, " ")}
+
+		minutes := map[string]int{}
+		for _, history := range task.Statuses {
+			minutes[history.Status] += history.Time
+		}
+		for _, status := range statuses {
+			row = append(row, minutes[status])
+		}
+
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// mergeRequestRows returns the header and the rows of the merge requests table
+func mergeRequestRows(data *DashboardData, lang string) [][]interface{} {
+	rows := [][]interface{}{{"ID", i18n.T(lang, "merge_requests.title"), i18n.T(lang, "merge_requests.tickets"),
+		i18n.T(lang, "merge_requests.created"), i18n.T(lang, "merge_requests.merged"), i18n.T(lang, "merge_requests.time"),
+		i18n.T(lang, "merge_requests.size"), i18n.T(lang, "merge_requests.additions"), i18n.T(lang, "merge_requests.deletions"),
+		i18n.T(lang, "merge_requests.files"), "Pipelines", "CI (min)", "CI %", i18n.T(lang, "merge_requests.retries"),
+		i18n.T(lang, "merge_requests.flaky_jobs"), "URL"}}
+
+	for _, mr := range data.MergeRequests {
+		rows = append(rows, []interface{}{mr.IID, mr.Title, strings.Join(mr.TaskIDs, " "), mr.CreatedAt, mr.MergedAt,
+			mr.TimeToMerge, mr.Size, mr.Additions, mr.Deletions, mr.FilesChanged, mr.Pipelines.Pipelines,
+			secondsToMinutes(mr.Pipelines.Duration), round(mr.Pipelines.CIShare), mr.Pipelines.Retries,
+			strings.Join(mr.Pipelines.FlakyJobs, " "), mr.WebUrl})
+	}
+	return rows
+}
+
+func writeCSV(w io.Writer, rows [][]interface{}) error {
+	writer := csv.NewWriter(w)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = fmt.Sprint(escapeFormula(value))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeTable(w io.Writer, rows [][]interface{}) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		values := make([]string, len(row))
+		for i, value := range row {
+			values[i] = fmt.Sprint(value)
+		}
+		fmt.Fprintln(writer, strings.Join(values, "\t"))
+	}
+	return writer.Flush()
+}
+
+func writeXLSX(w io.Writer, sheet string, rows [][]interface{}) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(row))
+		for j, value := range row {
+			values[j] = escapeFormula(value)
+		}
+		if err := file.SetSheetRow(sheet, cell, &values); err != nil {
+			return err
+		}
+	}
+	if err := file.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	return file.Write(w)
+}
+
+// escapeFormula prefixes the texts that spreadsheets would evaluate as a formula with a quote,
+// so a ticket or merge request title can't run one when the file is opened
+func escapeFormula(value interface{}) interface{} {
+	text, ok := value.(string)
+	if ok && text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return value
+}
+
+// round rounds the percentage to two decimals
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
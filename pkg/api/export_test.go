@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+)
+
+func TestTicketRows(t *testing.T) {
+	rows := ticketRows(&DashboardData{TaskMetrics: []TaskMetricsResponse{
+		{
+			Id:             "1",
+			FlowEfficiency: 66.666,
+			Statuses:       []data.History{{Status: "in development", Time: 30}, {Status: "blocked", Time: 10}},
+			MergeRequests:  []mergerequests.MergeRequest{{IID: 7}},
+		},
+		{
+			Id:       "2",
+			Statuses: []data.History{{Status: "in development", Time: 5}, {Status: "completed", Time: 0}},
+		},
+	}}, i18n.Default)
+
+	if len(rows) != 3 {
+		t.Fatalf("Cantidad de filas incorrecta, se esperaba %d pero se obtuvo %d", 3, len(rows))
+	}
+
+	header := rows[0][len(rows[0])-3:]
+	expectedHeader := []interface{}{"in development (min)", "blocked (min)", "completed (min)"}
+	if !reflect.DeepEqual(header, expectedHeader) {
+		t.Errorf("Encabezado incorrecto, se esperaba %v pero se obtuvo %v", expectedHeader, header)
+	}
+
+	first := rows[1]
+	if first[8] != 66.67 || first[11] != "!7" {
+		t.Errorf("Fila incorrecta, se obtuvo %v", first)
+	}
+
+	statuses := rows[2][len(rows[2])-3:]
+	expectedStatuses := []interface{}{5, 0, 0}
+	if !reflect.DeepEqual(statuses, expectedStatuses) {
+		t.Errorf("Tiempos por estado incorrectos, se esperaba %v pero se obtuvo %v", expectedStatuses, statuses)
+	}
+}
+
+func TestWriteExportTable(t *testing.T) {
+	var buf bytes.Buffer
+	data := &DashboardData{MergeRequests: []mergerequests.MergeRequest{{IID: 7, Title: "PRJ-1 Fix"}}}
+
+	if err := WriteExport(&buf, data, ExportTableMergeRequests, ExportFormatTable, i18n.Default); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "7   PRJ-1 Fix") {
+		t.Errorf("Tabla incorrecta, se obtuvo:\n%s", buf.String())
+	}
+}
+
+func TestWriteExportCSVEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	data := &DashboardData{MergeRequests: []mergerequests.MergeRequest{{IID: 7, Title: "=HYPERLINK(\"http://example.com\")"}}}
+
+	if err := WriteExport(&buf, data, ExportTableMergeRequests, ExportFormatCSV, i18n.Default); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], `7,"'=HYPERLINK(""http://example.com"")"`) {
+		t.Errorf("CSV incorrecto, se obtuvo:\n%s", buf.String())
+	}
+}
+
+func TestMergeRequestRowsTranslatesHeader(t *testing.T) {
+	tests := []struct {
+		lang     string
+		expected []interface{}
+	}{
+		{"es", []interface{}{"ID", "Título", "Tickets", "Creado", "Mergeado"}},
+		{"en", []interface{}{"ID", "Title", "Tickets", "Created", "Merged"}},
+	}
+
+	for _, test := range tests {
+		header := mergeRequestRows(&DashboardData{}, test.lang)[0][:5]
+		if !reflect.DeepEqual(header, test.expected) {
+			t.Errorf("Encabezado en %s incorrecto, se esperaba %v pero se obtuvo %v", test.lang, test.expected, header)
+		}
+	}
+}
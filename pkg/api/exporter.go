@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data/clickup"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/exporter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// exporterHandler serves the flow metrics of the store in the Prometheus format, it is nil when the store is not configured
+var exporterHandler http.Handler
+
+// configureExporter registers the collector of the flow metrics of the metrics store
+func configureExporter() {
+	if metricsStore == nil {
+		return
+	}
+
+	// The workflow of ClickUp is fixed, it doesn't need the session credentials
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter.NewCollector(metricsStore, createWorkflow(&clickup.Session{})))
+	exporterHandler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// getExporterHandler is the handler function for the GET /metrics endpoint scraped by Prometheus.
+// The metrics are computed from the snapshots of the metrics store.
+func getExporterHandler(w http.ResponseWriter, r *http.Request) {
+	if exporterHandler == nil {
+		writeError(w, r, errStoreNotConfigured)
+		return
+	}
+	exporterHandler.ServeHTTP(w, r)
+}
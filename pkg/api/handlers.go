@@ -2,41 +2,96 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
-	"text/template"
 
 	"github.com/gorilla/mux"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/dora"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
 	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
 )
 
 type ChartData struct {
-	ChartID    string
-	ChartLabel string
-	Data       []int
-	Labels     []string
+	ChartID    string   `json:"chart_id"`
+	ChartLabel string   `json:"chart_label"`
+	Data       []int    `json:"data"`
+	Labels     []string `json:"labels"`
 }
 type DashboardData struct {
-	StartDate               string
-	EndDate                 string
-	Prefix                  string
-	Tickets                 string
-	AvgLeadTime             int
-	AvgCycleTime            int
-	AvgBlockedTime          int
-	AvgFlowEfficiency       float64
-	TaskMetrics             []TaskMetricsResponse
-	LeadTimeData            ChartData
-	CycleTimeData           ChartData
-	BlockedTimeData         ChartData
-	FlowEfficiencyData      ChartData
-	MergeRequests           []mergerequests.MergeRequest
-	MergeRequestTimeToMerge ChartData
-	MergeRequestSize        ChartData
+	StartDate               string                       `json:"start_date"`
+	EndDate                 string                       `json:"end_date"`
+	Prefix                  string                       `json:"prefix"`
+	DateField               string                       `json:"date_field"`
+	Tickets                 string                       `json:"tickets"`
+	AvgLeadTime             int                          `json:"avg_lead_time"`
+	AvgCycleTime            int                          `json:"avg_cycle_time"`
+	AvgBlockedTime          int                          `json:"avg_blocked_time"`
+	AvgFlowEfficiency       float64                      `json:"avg_flow_efficiency"`
+	TaskMetrics             []TaskMetricsResponse        `json:"task_metrics"`
+	LeadTimeData            ChartData                    `json:"lead_time_chart"`
+	CycleTimeData           ChartData                    `json:"cycle_time_chart"`
+	BlockedTimeData         ChartData                    `json:"blocked_time_chart"`
+	FlowEfficiencyData      ChartData                    `json:"flow_efficiency_chart"`
+	CycleTimeScatter        ScatterData                  `json:"cycle_time_scatter"`
+	CycleTimeHistogram      ChartData                    `json:"cycle_time_histogram_chart"`
+	LeadTimeHistogram       ChartData                    `json:"lead_time_histogram_chart"`
+	CycleTimeDistribution   metrics.Distribution         `json:"cycle_time_distribution"`
+	LeadTimeDistribution    metrics.Distribution         `json:"lead_time_distribution"`
+	Buckets                 string                       `json:"buckets,omitempty"` // Upper bounds of the buckets of the histograms
+	MergeRequests           []mergerequests.MergeRequest `json:"merge_requests"`
+	MergeRequestTimeToMerge ChartData                    `json:"merge_request_time_to_merge_chart"`
+	MergeRequestSize        ChartData                    `json:"merge_request_size_chart"`
+	AvgCIShare              float64                      `json:"avg_ci_share"`
+	FlakyJobs               []FlakyJob                   `json:"flaky_jobs"`
+	Projects                string                       `json:"projects"`
+	Source                  string                       `json:"source"`
+	Team                    string                       `json:"team,omitempty"`
+	Period                  string                       `json:"period,omitempty"`
+	Trends                  []PeriodMetrics              `json:"trends,omitempty"`
+	Deltas                  *TrendDeltas                 `json:"deltas,omitempty"` // Last period versus the previous one
+	TrendCycleTimeData      ChartData                    `json:"trend_cycle_time_chart"`
+	TrendThroughputData     ChartData                    `json:"trend_throughput_chart"`
+	SLE                     *SLEReport                   `json:"sle,omitempty"` // Of the team of the params, if it has an SLE
+	SLEComplianceData       ChartData                    `json:"sle_compliance_chart"`
+	Dora                    *dora.Metrics                `json:"dora,omitempty"`
+	Deployments             []dora.Deployment            `json:"deployments,omitempty"`
+	Error                   string                       `json:"-"`
+	Lang                    string                       `json:"-"` // Language of the texts of the dashboard
+	ReportName              string                       `json:"-"` // Name of the saved report opened with its permalink
+	Teams                   []teams.Team                 `json:"-"` // Teams of the selector of the dashboard
+	Static                  bool                         `json:"-"` // Rendered as a file, without the filters of the dashboard
+}
+
+// DashboardParams are the filters of the dashboard, shared by every endpoint that builds a DashboardData
+type DashboardParams struct {
+	StartDate string
+	EndDate   string
+	Prefix    string
+	DateField mergerequests.DateField
+	Tickets   string
+	Projects  string
+	Refresh   bool   // Ignore the cached responses of ClickUp and GitLab
+	Source    string // SourceLive or SourceStore
+	Snapshot  int64  // Snapshot of the store the data is read as of, the latest one if 0
+	Lang      string // Language of the dashboard, the default one if empty
+	Team      string // Team whose configuration fills the empty filters, see applyTeam
+	Period    string // PeriodWeek or PeriodSprint to split the dates into periods, empty to not split them
+	Buckets   string // Comma separated upper bounds, in days, of the buckets of the histograms, the default ones if empty
+	team      *teams.Team
+}
+
+// FlakyJob is a CI job that failed and then passed in the pipelines of Count merge requests
+type FlakyJob struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
 }
 
 type Datos struct {
@@ -61,14 +116,16 @@ func getTaskMetricsHandler(w http.ResponseWriter, r *http.Request) {
 	taskID := vars["task_id"]
 
 	// Retrieve the task metrics for the specified task ID
-	taskMetrics, err := getTaskMetrics(taskID)
+	snapshotID, err := getSnapshot(r)
 	if err != nil {
-		switch err.Error() {
-		case "api key is expired or is not valid":
-			w.WriteHeader(http.StatusUnauthorized)
-			fmt.Fprint(w, `{"error": "Api Key is expired or is not valid. Get a new one and do the request again"}`)
-			return
-		}
+		writeError(w, r, err)
+		return
+	}
+
+	taskMetrics, err := getTaskMetrics(taskID, getSource(r), snapshotID, isRefresh(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
 	// Marshal the task metrics to JSON
@@ -92,54 +149,97 @@ func getTaskMetricsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func getDashboardHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract query parameters from the request
-	startDateParam := r.URL.Query().Get("start_date")
-	endDateParam := r.URL.Query().Get("end_date")
-	ticketsParam := r.URL.Query().Get("tickets")
-	prefixParam := r.URL.Query().Get("prefix")
-
-	tickets, err := url.QueryUnescape(ticketsParam)
+	lang := getLanguage(r)
+	params, err := parseDashboardParams(r)
 	if err != nil {
-		http.Error(w, "Error when decoding tickets param'", http.StatusBadRequest)
+		apiErr := toAPIError(err).localize(lang)
+		http.Error(w, apiErr.Message, apiErr.Status)
 		return
 	}
+	renderDashboard(w, params, "")
+}
 
-	// Register the toJson function as a custom template function
-	funcMap := template.FuncMap{
-		"toJson": toJson,
-	}
-
-	// Compilar la plantilla desde el archivo
-	tmpl, err := template.New("dashboard.gohtml").Funcs(funcMap).
-		ParseFiles("templates/dashboard.gohtml",
-			"templates/average_metrics.gohtml",
-			"templates/line_chart.gohtml",
-			"templates/bar_chart.gohtml",
-			"templates/no_data.gohtml",
-			"templates/tickets_table.gohtml",
-			"templates/merge_requests_table.gohtml",
-			"templates/scripts.gohtml")
-
+// renderDashboard writes the dashboard with the data of the filters, titled with the name of the saved report if any
+func renderDashboard(w http.ResponseWriter, params DashboardParams, reportName string) {
+	lang := params.Lang
+	tmpl, err := getDashboardTemplates(lang)
 	if err != nil {
 		log.Println("Error when reading template: ", err)
-		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		http.Error(w, i18n.T(lang, "error.internal"), http.StatusInternalServerError)
 		return
 	}
 
-	data, _ := getDashboardData(startDateParam, endDateParam, prefixParam, tickets)
+	data, err := getDashboardData(params)
+	data.ReportName = reportName
+	data.Teams = teamRegistry.Teams()
+	if err != nil {
+		// The dashboard is rendered anyway, with whatever data could be retrieved and the error
+		apiErr := toAPIError(err).localize(lang)
+		data.Error = apiErr.Message
+		w.WriteHeader(apiErr.Status)
+	}
 
 	// Rellenar la plantilla con los datos y escribir la respuesta HTTP
 	err = tmpl.Execute(w, data)
 	if err != nil {
 		log.Println("Error when executing template: ", err)
-		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		http.Error(w, i18n.T(lang, "error.internal"), http.StatusInternalServerError)
 		return
 	}
 }
 
-func getClickUpData(result *DashboardData, tickets string) {
-	if tickets == "" {
-		return
+// parseDashboardParams extracts the dashboard filters from the query parameters of the request
+func parseDashboardParams(r *http.Request) (DashboardParams, error) {
+	tickets, err := url.QueryUnescape(r.URL.Query().Get("tickets"))
+	if err != nil {
+		return DashboardParams{}, newBadRequestError("error.invalid_tickets")
+	}
+	snapshotID, err := getSnapshot(r)
+	if err != nil {
+		return DashboardParams{}, err
+	}
+	period := r.URL.Query().Get("period")
+	if !isValidPeriod(period) {
+		return DashboardParams{}, newBadRequestError("error.invalid_period")
+	}
+
+	return DashboardParams{
+		StartDate: r.URL.Query().Get("start_date"),
+		EndDate:   r.URL.Query().Get("end_date"),
+		Prefix:    r.URL.Query().Get("prefix"),
+		DateField: mergerequests.ParseDateField(r.URL.Query().Get("date_field")),
+		Tickets:   tickets,
+		Projects:  r.URL.Query().Get("projects"),
+		Refresh:   isRefresh(r),
+		Source:    getSource(r),
+		Snapshot:  snapshotID,
+		Lang:      getLanguage(r),
+		Team:      r.URL.Query().Get("team"),
+		Period:    period,
+		Buckets:   r.URL.Query().Get("buckets"),
+	}, nil
+}
+
+// getClickUpData fills the ticket metrics of the dashboard. Tickets that can't be retrieved are skipped,
+// unless the error affects every ticket, like an expired API key.
+// With a team and without tickets, the tasks of the lists of the team are used, with the workflow of the team.
+func getClickUpData(result *DashboardData, params DashboardParams) error {
+	tickets, refresh := params.Tickets, params.Refresh
+	if tickets == "" && params.team == nil {
+		return nil
+	}
+	tasks, err := newTaskSource(params.Source, params.Snapshot)
+	if err != nil {
+		return err
+	}
+	if params.team != nil {
+		tasks.wf = teamWorkflow(tasks.wf, *params.team)
+		if tickets == "" {
+			tickets, err = teamTickets(tasks, *params.team, params.StartDate)
+			if err != nil || tickets == "" {
+				return err
+			}
+		}
 	}
 
 	ticketsSlice := strings.Split(tickets, ",")
@@ -156,7 +256,13 @@ func getClickUpData(result *DashboardData, tickets string) {
 
 		ticketIdStr := strings.ReplaceAll(ticketId, "#", "")
 		ticketIdStr = strings.ReplaceAll(ticketIdStr, " ", "")
-		ticketMetrics, err := getTaskMetrics(ticketIdStr)
+		if refresh {
+			tasks.refresh(ticketIdStr)
+		}
+		ticketMetrics, err := calculateTaskMetrics(tasks, ticketIdStr)
+		if errors.Is(err, data.ErrUnauthorized) || errors.Is(err, data.ErrRateLimited) {
+			return err
+		}
 		if err != nil {
 			log.Println(err)
 			continue
@@ -164,7 +270,6 @@ func getClickUpData(result *DashboardData, tickets string) {
 		result.AvgLeadTime = result.AvgLeadTime + ticketMetrics.LeadTime
 		result.AvgCycleTime = result.AvgCycleTime + ticketMetrics.CycleTime
 		result.AvgBlockedTime = result.AvgBlockedTime + ticketMetrics.BlockedTime
-		result.AvgFlowEfficiency = result.AvgFlowEfficiency + ticketMetrics.FlowEfficiency
 		leadTimeDataSlice = append(leadTimeDataSlice, ticketMetrics.LeadTime)
 		leadTimeLabelsSlice = append(leadTimeLabelsSlice, ticketMetrics.CustomId)
 		cycleTimeDataSlice = append(cycleTimeDataSlice, ticketMetrics.CycleTime)
@@ -176,10 +281,16 @@ func getClickUpData(result *DashboardData, tickets string) {
 		result.TaskMetrics = append(result.TaskMetrics, ticketMetrics)
 	}
 
-	result.AvgLeadTime = result.AvgLeadTime / len(ticketsSlice)
-	result.AvgCycleTime = result.AvgCycleTime / len(ticketsSlice)
-	result.AvgBlockedTime = result.AvgBlockedTime / len(ticketsSlice)
-	result.AvgFlowEfficiency = (float64(result.AvgCycleTime) - float64(result.AvgBlockedTime)) * 100 / float64(result.AvgCycleTime)
+	// The tickets that couldn't be retrieved don't count in the averages
+	if retrieved := len(result.TaskMetrics); retrieved > 0 {
+		result.AvgLeadTime = result.AvgLeadTime / retrieved
+		result.AvgCycleTime = result.AvgCycleTime / retrieved
+		result.AvgBlockedTime = result.AvgBlockedTime / retrieved
+	}
+	result.AvgFlowEfficiency = 0
+	if result.AvgCycleTime > 0 {
+		result.AvgFlowEfficiency = (float64(result.AvgCycleTime) - float64(result.AvgBlockedTime)) * 100 / float64(result.AvgCycleTime)
+	}
 
 	result.LeadTimeData = ChartData{
 		ChartID:    "lead-time-chart",
@@ -208,19 +319,22 @@ func getClickUpData(result *DashboardData, tickets string) {
 		Data:       flowEfficiencyDataSlice,
 		Labels:     flowEfficiencyLabalsSlice,
 	}
+
+	return nil
 }
 
-func getGitLabData(result *DashboardData, startDate string, endDate string, prefix string) {
+func getGitLabData(result *DashboardData, startDate string, endDate string, prefix string, dateField mergerequests.DateField, source string, snapshotID int64, refresh bool) error {
 	if startDate == "" || endDate == "" {
-		return
+		return nil
 	}
-	gitlabToken := os.Getenv("GITLAB_TOKEN")
-	if gitlabToken == "" {
-		log.Fatalln("GitLab token has not been set")
-		return
+	mrsCli, err := newMergeRequestSource(prefix, source, snapshotID, refresh)
+	if err != nil {
+		return err
+	}
+	mrsSlice, err := mrsCli.GetMergeRequestsBetween(startDate, endDate, dateField)
+	if err != nil {
+		return err
 	}
-	mrsCli := mergerequests.NewGitlabClient("5908940", prefix, gitlabToken)
-	mrsSlice, _ := mrsCli.GetMergeRequestsMergedBetween(startDate, endDate)
 
 	result.MergeRequests = mrsSlice
 
@@ -229,24 +343,136 @@ func getGitLabData(result *DashboardData, startDate string, endDate string, pref
 	for _, mr := range result.MergeRequests {
 		result.MergeRequestTimeToMerge = appendMergeRequestTimeToMergeChartData(result.MergeRequestTimeToMerge, mr)
 		result.MergeRequestSize = appendMergeRequestSizeChartData(result.MergeRequestSize, mr)
+		result.AvgCIShare = result.AvgCIShare + mr.Pipelines.CIShare
+		result.FlakyJobs = appendFlakyJobs(result.FlakyJobs, mr.Pipelines.FlakyJobs)
+	}
+
+	if len(result.MergeRequests) > 0 {
+		result.AvgCIShare = result.AvgCIShare / float64(len(result.MergeRequests))
 	}
+	sort.Slice(result.FlakyJobs, func(i, j int) bool {
+		return result.FlakyJobs[i].Count > result.FlakyJobs[j].Count
+	})
+
+	return nil
 }
 
-func getDashboardData(startDate string, endDate string, prefix string, tickets string) (*DashboardData, error) {
+// appendFlakyJobs counts one more occurrence of each of the job names
+func appendFlakyJobs(flakyJobs []FlakyJob, names []string) []FlakyJob {
+	for _, name := range names {
+		found := false
+		for i := range flakyJobs {
+			if flakyJobs[i].Name == name {
+				flakyJobs[i].Count++
+				found = true
+				break
+			}
+		}
+		if !found {
+			flakyJobs = append(flakyJobs, FlakyJob{Name: name, Count: 1})
+		}
+	}
+	return flakyJobs
+}
+
+var errGitlabNotConfigured = newNotConfiguredError("error.gitlab_not_configured")
+
+// newGitlabClient creates a GitLab client for the merge requests whose title contains prefix
+func newGitlabClient(prefix string) (*mergerequests.GitlabClient, error) {
+	gitlabToken := os.Getenv("GITLAB_TOKEN")
+	if gitlabToken == "" {
+		return nil, errGitlabNotConfigured
+	}
+	mrsCli := mergerequests.NewGitlabClient("5908940", prefix, gitlabToken)
+	mrsCli.Cache = cacheStore
+	mrsCli.CacheTTL = cacheTTL
+	if excludedPaths := splitList(os.Getenv("GITLAB_EXCLUDED_PATHS")); len(excludedPaths) > 0 {
+		mrsCli.ExcludedPaths = excludedPaths
+	}
+	return mrsCli, nil
+}
+
+func getDashboardData(params DashboardParams) (*DashboardData, error) {
 	result := &DashboardData{
-		StartDate: startDate,
-		EndDate:   endDate,
-		Tickets:   tickets,
-		Prefix:    prefix,
+		StartDate: params.StartDate,
+		EndDate:   params.EndDate,
+		Tickets:   params.Tickets,
+		Prefix:    params.Prefix,
+		DateField: string(params.DateField),
+		Projects:  params.Projects,
+		Source:    params.Source,
+		Lang:      params.Lang,
+		Team:      params.Team,
+		Period:    params.Period,
+		Buckets:   params.Buckets,
+	}
+
+	params, err := applyTeam(params)
+	if err != nil {
+		return result, err
 	}
+	result.Prefix, result.Projects = params.Prefix, params.Projects
 
-	getClickUpData(result, tickets)
+	// Every section is filled even if a previous one failed, the first error is returned
+	errs := []error{
+		getClickUpData(result, params),
+		getGitLabData(result, params.StartDate, params.EndDate, params.Prefix, params.DateField, params.Source, params.Snapshot, params.Refresh),
+	}
+
+	result.CycleTimeScatter = newCycleTimeScatter(result.TaskMetrics)
+	errs = append(errs, getDistributionData(result, params.Buckets))
+	linkMergeRequests(result)
+	errs = append(errs, getTrendData(result, params), getSLEData(result, params))
 
-	getGitLabData(result, startDate, endDate, prefix)
+	// The deployments are not stored, the DORA metrics are only available from GitLab
+	if params.Source != SourceStore {
+		errs = append(errs, getDoraData(result, params.StartDate, params.EndDate, params.Projects, params.Refresh))
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return result, err
+		}
+	}
 
 	return result, nil
 }
 
+// customIDPrefixes returns the prefixes of the custom ids of the tickets: the prefix of the report and the ones
+// of the configured teams
+func customIDPrefixes(prefix string) []string {
+	prefixes := []string{prefix}
+	for _, team := range teamRegistry.Teams() {
+		prefixes = append(prefixes, team.Prefix)
+	}
+	return prefixes
+}
+
+// linkMergeRequests parses the tickets mentioned by every merge request, associates every ticket with the merge
+// requests that mention it and splits its cycle time into coding time and review time
+func linkMergeRequests(result *DashboardData) {
+	prefixes := customIDPrefixes(result.Prefix)
+	for i := range result.MergeRequests {
+		result.MergeRequests[i].TaskIDs = mergerequests.ParseTaskIDs(result.MergeRequests[i], prefixes...)
+	}
+
+	for i := range result.TaskMetrics {
+		task := &result.TaskMetrics[i]
+		task.MergeRequests = mergerequests.LinkToTask(result.MergeRequests, task.CustomId, task.Id)
+		if len(task.MergeRequests) == 0 {
+			continue
+		}
+
+		// Both are calendar days. The review can be longer than the cycle time when a merge request
+		// was opened before the ticket entered a cycle time status.
+		task.ReviewTime = mergerequests.ReviewTime(task.MergeRequests)
+		task.CodingTime = task.CycleTime - task.ReviewTime
+		if task.CodingTime < 0 {
+			task.CodingTime = 0
+		}
+	}
+}
+
 func initMergeRequestSizeChartData() ChartData {
 	return ChartData{
 		ChartID:    "merge-request-size-chart",
@@ -289,7 +515,7 @@ func appendMergeRequestTimeToMergeChartData(timeToMerge ChartData, mr mergereque
 	days := mr.TimeToMerge
 
 	switch {
-	case days < 0:
+	case mr.MergedAt == "" || days < 0:
 		return timeToMerge
 	case days >= 7:
 		timeToMerge.Data[MoreThan7Days]++
@@ -300,6 +526,11 @@ func appendMergeRequestTimeToMergeChartData(timeToMerge ChartData, mr mergereque
 	return timeToMerge
 }
 
+// secondsToMinutes converts a duration in seconds to whole minutes, rounding up
+func secondsToMinutes(seconds int) int {
+	return (seconds + 59) / 60
+}
+
 func toJson(v interface{}) (string, error) {
 	jsonData, err := json.Marshal(v)
 	if err != nil {
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+)
+
+const maxBuckets = 20
+
+// defaultDayBuckets are the upper bounds, in days, of the cycle time and lead time histograms
+var defaultDayBuckets = []int{1, 2, 3, 5, 8, 13, 21}
+
+// parseBuckets parses the comma separated upper bounds of the buckets of the histograms, in days.
+// The bounds must increase, the last bucket has the values above the last bound.
+func parseBuckets(value string) ([]int, error) {
+	if value == "" {
+		return defaultDayBuckets, nil
+	}
+	buckets := []int{}
+	for _, field := range strings.Split(value, ",") {
+		bound, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || bound < 0 || (len(buckets) > 0 && bound <= buckets[len(buckets)-1]) {
+			return nil, newBadRequestError("error.invalid_buckets", maxBuckets)
+		}
+		buckets = append(buckets, bound)
+	}
+	if len(buckets) > maxBuckets {
+		return nil, newBadRequestError("error.invalid_buckets", maxBuckets)
+	}
+	return buckets, nil
+}
+
+// bucketLabels returns the labels of the buckets, e.g. ≤1d, 2d, 3-5d and +5d
+func bucketLabels(buckets []int) []string {
+	labels := []string{}
+	for i, bound := range buckets {
+		switch {
+		case i == 0:
+			labels = append(labels, fmt.Sprintf("≤%dd", bound))
+		case bound == buckets[i-1]+1:
+			labels = append(labels, fmt.Sprintf("%dd", bound))
+		default:
+			labels = append(labels, fmt.Sprintf("%d-%dd", buckets[i-1]+1, bound))
+		}
+	}
+	return append(labels, fmt.Sprintf("+%dd", buckets[len(buckets)-1]))
+}
+
+// newDaysHistogram counts the values, in days, of every bucket
+func newDaysHistogram(chartID string, chartLabel string, values []int, buckets []int) ChartData {
+	histogram := ChartData{
+		ChartID:    chartID,
+		ChartLabel: chartLabel,
+		Data:       make([]int, len(buckets)+1),
+		Labels:     bucketLabels(buckets),
+	}
+	for _, value := range values {
+		index := len(buckets)
+		for i, bound := range buckets {
+			if value <= bound {
+				index = i
+				break
+			}
+		}
+		histogram.Data[index]++
+	}
+	return histogram
+}
+
+// getDistributionData fills the histograms and the distributions of the cycle time and the lead time of the tickets
+func getDistributionData(result *DashboardData, bucketsParam string) error {
+	buckets, err := parseBuckets(bucketsParam)
+	if err != nil {
+		return err
+	}
+
+	cycleTimes, leadTimes := []int{}, []int{}
+	for _, task := range result.TaskMetrics {
+		cycleTimes = append(cycleTimes, task.CycleTime)
+		leadTimes = append(leadTimes, task.LeadTime)
+	}
+	result.CycleTimeHistogram = newDaysHistogram("cycle-time-histogram-chart", "Cycle Time - Histogram", cycleTimes, buckets)
+	result.LeadTimeHistogram = newDaysHistogram("lead-time-histogram-chart", "Lead Time - Histogram", leadTimes, buckets)
+	result.CycleTimeDistribution = metrics.NewDistribution(cycleTimes)
+	result.LeadTimeDistribution = metrics.NewDistribution(leadTimes)
+	return nil
+}
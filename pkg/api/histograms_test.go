@@ -0,0 +1,47 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBuckets(t *testing.T) {
+	buckets, err := parseBuckets("1, 3,7")
+	if err != nil || !reflect.DeepEqual(buckets, []int{1, 3, 7}) {
+		t.Errorf("Buckets incorrectos, se obtuvo %v (%v)", buckets, err)
+	}
+	if buckets, _ := parseBuckets(""); !reflect.DeepEqual(buckets, defaultDayBuckets) {
+		t.Errorf("Se esperaban los buckets por defecto pero se obtuvo %v", buckets)
+	}
+	for _, value := range []string{"3,1", "1,1", "a", "-1,2"} {
+		if _, err := parseBuckets(value); err == nil {
+			t.Errorf("Se esperaba un error para los buckets %s", value)
+		}
+	}
+}
+
+func TestGetDistributionData(t *testing.T) {
+	result := &DashboardData{TaskMetrics: []TaskMetricsResponse{
+		{Id: "1", CycleTime: 0, LeadTime: 2},
+		{Id: "2", CycleTime: 2, LeadTime: 4},
+		{Id: "3", CycleTime: 3, LeadTime: 6},
+		{Id: "4", CycleTime: 9, LeadTime: 12},
+	}}
+	if err := getDistributionData(result, "1,2,5"); err != nil {
+		t.Fatal(err)
+	}
+
+	histogram := result.CycleTimeHistogram
+	if !reflect.DeepEqual(histogram.Labels, []string{"≤1d", "2d", "3-5d", "+5d"}) {
+		t.Errorf("Etiquetas incorrectas, se obtuvo %v", histogram.Labels)
+	}
+	if !reflect.DeepEqual(histogram.Data, []int{1, 1, 1, 1}) {
+		t.Errorf("Histograma de cycle time incorrecto, se obtuvo %v", histogram.Data)
+	}
+	if !reflect.DeepEqual(result.LeadTimeHistogram.Data, []int{0, 1, 1, 2}) {
+		t.Errorf("Histograma de lead time incorrecto, se obtuvo %v", result.LeadTimeHistogram.Data)
+	}
+	if result.LeadTimeDistribution.Mean != 6 || result.CycleTimeDistribution.Skewness <= 0 {
+		t.Errorf("Distribuciones incorrectas, se obtuvo %+v y %+v", result.CycleTimeDistribution, result.LeadTimeDistribution)
+	}
+}
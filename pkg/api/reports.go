@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/dora"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+)
+
+// ReportVersion is the version of the JSON schema returned by GET /api/reports.
+// It must be increased whenever a field of the report is renamed or removed.
+const ReportVersion = 2
+
+type ReportResponse struct {
+	Version     int    `json:"version"`
+	GeneratedAt string `json:"generated_at"`
+	Report      Report `json:"report"`
+}
+
+// Report is the public schema of the dashboard report. It is kept apart from DashboardData,
+// so the fields the templates need can change without breaking the clients of the API.
+type Report struct {
+	StartDate     string                          `json:"start_date"`
+	EndDate       string                          `json:"end_date"`
+	Prefix        string                          `json:"prefix"`
+	DateField     string                          `json:"date_field"`
+	Tickets       string                          `json:"tickets"`
+	Projects      string                          `json:"projects"`
+	Source        string                          `json:"source"`
+	Team          string                          `json:"team,omitempty"`
+	Period        string                          `json:"period,omitempty"`
+	Averages      ReportAverages                  `json:"averages"`
+	TaskMetrics   []TaskMetricsResponse           `json:"task_metrics"`
+	MergeRequests []mergerequests.MergeRequest    `json:"merge_requests"`
+	FlakyJobs     []FlakyJob                      `json:"flaky_jobs"`
+	Charts        map[string]ReportSeries         `json:"charts"`
+	Scatter       ScatterData                     `json:"cycle_time_scatter"`
+	Distributions map[string]metrics.Distribution `json:"distributions"` // Of the cycle time and the lead time, in days
+	Dora          *dora.Metrics                   `json:"dora,omitempty"`
+	Deployments   []dora.Deployment               `json:"deployments,omitempty"` // Lead times in hours
+	Trends        []PeriodMetrics                 `json:"trends,omitempty"`
+	Deltas        *TrendDeltas                    `json:"deltas,omitempty"`
+	SLE           *SLEReport                      `json:"sle,omitempty"`
+}
+
+// ReportAverages are the averages of the tickets, in days, and of the merge requests
+type ReportAverages struct {
+	LeadTime       int     `json:"lead_time"`
+	CycleTime      int     `json:"cycle_time"`
+	BlockedTime    int     `json:"blocked_time"`
+	FlowEfficiency float64 `json:"flow_efficiency"`
+	CIShare        float64 `json:"ci_share"`
+}
+
+// ReportSeries is the data of a chart of the dashboard
+type ReportSeries struct {
+	Labels []string `json:"labels"`
+	Data   []int    `json:"data"`
+}
+
+// NewReportResponse converts the dashboard data into the versioned report of GET /api/reports
+func NewReportResponse(data *DashboardData) ReportResponse {
+	series := func(chart ChartData) ReportSeries {
+		return ReportSeries{Labels: chart.Labels, Data: chart.Data}
+	}
+
+	return ReportResponse{
+		Version:     ReportVersion,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Report: Report{
+			StartDate: data.StartDate,
+			EndDate:   data.EndDate,
+			Prefix:    data.Prefix,
+			DateField: data.DateField,
+			Tickets:   data.Tickets,
+			Projects:  data.Projects,
+			Source:    data.Source,
+			Team:      data.Team,
+			Period:    data.Period,
+			Averages: ReportAverages{
+				LeadTime:       data.AvgLeadTime,
+				CycleTime:      data.AvgCycleTime,
+				BlockedTime:    data.AvgBlockedTime,
+				FlowEfficiency: data.AvgFlowEfficiency,
+				CIShare:        data.AvgCIShare,
+			},
+			TaskMetrics:   data.TaskMetrics,
+			MergeRequests: data.MergeRequests,
+			FlakyJobs:     data.FlakyJobs,
+			Charts: map[string]ReportSeries{
+				"lead_time":                   series(data.LeadTimeData),
+				"cycle_time":                  series(data.CycleTimeData),
+				"blocked_time":                series(data.BlockedTimeData),
+				"flow_efficiency":             series(data.FlowEfficiencyData),
+				"merge_request_time_to_merge": series(data.MergeRequestTimeToMerge),
+				"merge_request_size":          series(data.MergeRequestSize),
+				"cycle_time_histogram":        series(data.CycleTimeHistogram),
+				"lead_time_histogram":         series(data.LeadTimeHistogram),
+			},
+			Scatter: data.CycleTimeScatter,
+			Distributions: map[string]metrics.Distribution{
+				"cycle_time": data.CycleTimeDistribution,
+				"lead_time":  data.LeadTimeDistribution,
+			},
+			Dora:        data.Dora,
+			Deployments: data.Deployments,
+			Trends:      data.Trends,
+			Deltas:      data.Deltas,
+			SLE:         data.SLE,
+		},
+	}
+}
+
+// getReportHandler is the handler function for the GET /api/reports endpoint.
+// It returns the same data rendered by the dashboard as JSON.
+func getReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params, err := parseDashboardParams(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	data, err := getDashboardData(params)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(NewReportResponse(data))
+	if err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+)
+
+func TestNewReportResponse(t *testing.T) {
+	data := &DashboardData{
+		StartDate:    "2024-01-01",
+		AvgCycleTime: 4,
+		CycleTimeData: ChartData{
+			ChartID: "cycle-time-chart",
+			Labels:  []string{"1", "2"},
+			Data:    []int{3, 1},
+		},
+		Error:  "template only",
+		Static: true,
+	}
+
+	response := NewReportResponse(data)
+	if response.Version != ReportVersion || response.Report.Averages.CycleTime != 4 {
+		t.Errorf("Reporte incorrecto: %+v", response)
+	}
+	if len(response.Report.Charts["cycle_time"].Data) != 2 {
+		t.Errorf("Serie de cycle time incorrecta: %+v", response.Report.Charts["cycle_time"])
+	}
+
+	content, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "chart_id") || strings.Contains(string(content), "template only") {
+		t.Errorf("El reporte incluye campos de los templates: %s", content)
+	}
+}
+
+func TestReportAveragesWithFailedTickets(t *testing.T) {
+	t.Setenv("API_KEY", "test")
+	store, err := storage.Open(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricsStore = store
+	defer func() { metricsStore = nil }()
+
+	// 2024-01-10, in milliseconds
+	tasks := []data.TaskInfo{
+		newListTask("1", "901", "completed", 4*24*60, "1704880800000"),
+		newListTask("2", "901", "completed", 60, "1704880800000"),
+	}
+	if _, err := store.SaveSnapshot(storage.Snapshot{Tasks: tasks}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tickets   string
+		cycleTime int
+	}{
+		{tickets: "1,missing", cycleTime: 4},
+		{tickets: "missing,other", cycleTime: 0},
+		// A 1-day ticket averaged with the failed one was 0 days, and its flow efficiency NaN
+		{tickets: "2,missing", cycleTime: 1},
+	}
+	for _, test := range tests {
+		result := &DashboardData{}
+		if err := getClickUpData(result, DashboardParams{Tickets: test.tickets, Source: SourceStore}); err != nil {
+			t.Fatal(err)
+		}
+		if result.AvgCycleTime != test.cycleTime {
+			t.Errorf("Cycle time promedio incorrecto para %s, se esperaba %d pero se obtuvo %d", test.tickets, test.cycleTime, result.AvgCycleTime)
+		}
+		if test.cycleTime == 0 && result.AvgFlowEfficiency != 0 {
+			t.Errorf("Flow efficiency incorrecta para %s, se esperaba 0 pero se obtuvo %f", test.tickets, result.AvgFlowEfficiency)
+		}
+		if _, err := json.Marshal(NewReportResponse(result)); err != nil {
+			t.Errorf("Error al codificar el reporte de %s: %v", test.tickets, err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+// maxReportNameLength is the maximum length of the name of a saved report
+const maxReportNameLength = 200
+
+// SavedReportRequest is the body of POST /api/saved-reports: the name and the filters of the dashboard
+type SavedReportRequest struct {
+	Name      string `json:"name"`
+	Tickets   string `json:"tickets"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Prefix    string `json:"prefix"`
+	DateField string `json:"date_field"`
+	Projects  string `json:"projects"`
+	Source    string `json:"source"`
+	Team      string `json:"team"`
+	Period    string `json:"period"`
+}
+
+// SavedReportResponse is a saved report with its permalink
+type SavedReportResponse struct {
+	SavedReportRequest
+	Slug      string `json:"slug"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"created_at"`
+}
+
+// SavedReportsPage is the data of the page that lists the saved reports
+type SavedReportsPage struct {
+	Reports []SavedReportResponse
+	Team    string // Team whose reports are listed, every report if empty
+	Teams   []teams.Team
+	Error   string
+	Lang    string
+}
+
+// newSavedReportResponse returns the saved report with the permalink it is opened with
+func newSavedReportResponse(report storage.SavedReport) SavedReportResponse {
+	return SavedReportResponse{
+		SavedReportRequest: SavedReportRequest{
+			Name:      report.Name,
+			Tickets:   report.Tickets,
+			StartDate: report.StartDate,
+			EndDate:   report.EndDate,
+			Prefix:    report.Prefix,
+			DateField: report.DateField,
+			Projects:  report.Projects,
+			Source:    report.Source,
+			Team:      report.Team,
+			Period:    report.Period,
+		},
+		Slug:      report.Slug,
+		URL:       "/r/" + report.Slug,
+		CreatedAt: report.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// validate checks the request and normalizes its filters the same way the dashboard does
+func (req *SavedReportRequest) validate() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxReportNameLength {
+		return newBadRequestError("error.invalid_report_name", maxReportNameLength)
+	}
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return newBadRequestError("error.invalid_report_dates")
+		}
+	}
+	if req.EndDate < req.StartDate && req.EndDate != "" {
+		return newBadRequestError("error.invalid_report_dates")
+	}
+	if _, err := parseProjectIDs(req.Projects); err != nil {
+		return newBadRequestError("error.invalid_projects")
+	}
+	if req.Team != "" {
+		if _, err := teamRegistry.Get(req.Team); err != nil {
+			return newBadRequestError("error.unknown_team", req.Team)
+		}
+	}
+	if !isValidPeriod(req.Period) {
+		return newBadRequestError("error.invalid_period")
+	}
+	req.DateField = string(mergerequests.ParseDateField(req.DateField))
+	if req.Source != SourceStore {
+		req.Source = SourceLive
+	}
+	return nil
+}
+
+// savedReportParams returns the dashboard filters of the saved report
+func savedReportParams(report storage.SavedReport, lang string) DashboardParams {
+	return DashboardParams{
+		StartDate: report.StartDate,
+		EndDate:   report.EndDate,
+		Prefix:    report.Prefix,
+		DateField: mergerequests.ParseDateField(report.DateField),
+		Tickets:   report.Tickets,
+		Projects:  report.Projects,
+		Source:    report.Source,
+		Team:      report.Team,
+		Period:    report.Period,
+		Lang:      lang,
+	}
+}
+
+// createSavedReportHandler is the handler function for the POST /api/saved-reports endpoint.
+// It saves the name and the filters of a report in the metrics store and returns its permalink.
+func createSavedReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if metricsStore == nil {
+		writeError(w, r, errStoreNotConfigured)
+		return
+	}
+
+	var request SavedReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, newBadRequestError("error.invalid_saved_report_body"))
+		return
+	}
+	if err := request.validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	report := storage.SavedReport{
+		Name:      request.Name,
+		Tickets:   request.Tickets,
+		StartDate: request.StartDate,
+		EndDate:   request.EndDate,
+		Prefix:    request.Prefix,
+		DateField: request.DateField,
+		Projects:  request.Projects,
+		Source:    request.Source,
+		Team:      request.Team,
+		Period:    request.Period,
+		CreatedAt: time.Now(),
+	}
+	slug, err := metricsStore.SaveReport(report)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	report.Slug = slug
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newSavedReportResponse(report)); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// getSavedReportsHandler is the handler function for the GET /api/saved-reports endpoint.
+// It lists the saved reports of the team param, or every saved report without it, most recent first.
+func getSavedReportsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	reports, err := getSavedReports(r.URL.Query().Get("team"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// getSavedReportHandler is the handler function for the GET /api/saved-reports/{slug} endpoint
+func getSavedReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if metricsStore == nil {
+		writeError(w, r, errStoreNotConfigured)
+		return
+	}
+	report, err := metricsStore.GetReport(mux.Vars(r)["slug"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(newSavedReportResponse(report)); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// getSavedReportDashboardHandler is the handler function for the /r/{slug} permalinks.
+// It renders the dashboard with the filters of the saved report, so the URL stays short however many tickets it has.
+func getSavedReportDashboardHandler(w http.ResponseWriter, r *http.Request) {
+	lang := getLanguage(r)
+	if metricsStore == nil {
+		apiErr := errStoreNotConfigured.localize(lang)
+		http.Error(w, apiErr.Message, apiErr.Status)
+		return
+	}
+
+	report, err := metricsStore.GetReport(mux.Vars(r)["slug"])
+	if err != nil {
+		apiErr := toAPIError(err).localize(lang)
+		http.Error(w, apiErr.Message, apiErr.Status)
+		return
+	}
+
+	params := savedReportParams(report, lang)
+	params.Refresh = isRefresh(r)
+	renderDashboard(w, params, report.Name)
+}
+
+// getSavedReportsPageHandler is the handler function for the GET /saved-reports page, with a link to every saved report
+// of the team param
+func getSavedReportsPageHandler(w http.ResponseWriter, r *http.Request) {
+	lang := getLanguage(r)
+	tmpl, err := getDashboardTemplates(lang)
+	if err != nil {
+		log.Println("Error when reading template: ", err)
+		http.Error(w, i18n.T(lang, "error.internal"), http.StatusInternalServerError)
+		return
+	}
+
+	page := SavedReportsPage{Team: r.URL.Query().Get("team"), Teams: teamRegistry.Teams(), Lang: lang}
+	page.Reports, err = getSavedReports(page.Team)
+	if err != nil {
+		apiErr := toAPIError(err).localize(lang)
+		page.Error = apiErr.Message
+		w.WriteHeader(apiErr.Status)
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "saved_reports", page); err != nil {
+		log.Println("Error when executing template: ", err)
+		http.Error(w, i18n.T(lang, "error.internal"), http.StatusInternalServerError)
+	}
+}
+
+// getSavedReports returns the saved reports of the team with their permalinks, or every saved report if team is empty
+func getSavedReports(team string) ([]SavedReportResponse, error) {
+	if metricsStore == nil {
+		return nil, errStoreNotConfigured
+	}
+	reports, err := metricsStore.GetReports(team)
+	if err != nil {
+		return nil, err
+	}
+
+	response := []SavedReportResponse{}
+	for _, report := range reports {
+		response = append(response, newSavedReportResponse(report))
+	}
+	return response, nil
+}
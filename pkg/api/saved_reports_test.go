@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+)
+
+func TestSavedReports(t *testing.T) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricsStore = store
+	defer func() { metricsStore = nil }()
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"name": "Sprint <12>", "tickets": "PRJ-1,PRJ-2", "start_date": "2024-01-01", "end_date": "2024-01-14"}`, http.StatusCreated},
+		{`{"name": " "}`, http.StatusBadRequest},
+		{`{"name": "Sprint 13", "start_date": "2024-01-14", "end_date": "2024-01-01"}`, http.StatusBadRequest},
+		{`{"name": "Sprint 13", "projects": "abc"}`, http.StatusBadRequest},
+		{`{"name": "Sprint 13", "team": "missing"}`, http.StatusBadRequest},
+		{`[]`, http.StatusBadRequest},
+	}
+	var created SavedReportResponse
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		createSavedReportHandler(recorder, httptest.NewRequest("POST", "/api/saved-reports", strings.NewReader(test.body)))
+		if recorder.Code != test.status {
+			t.Errorf("Status incorrecto para %s, se esperaba %d pero se obtuvo %d", test.body, test.status, recorder.Code)
+		}
+		if recorder.Code == http.StatusCreated {
+			if err := json.NewDecoder(recorder.Body).Decode(&created); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if created.URL != "/r/"+created.Slug || created.Source != SourceLive || created.DateField != "merged" {
+		t.Errorf("Reporte guardado incorrecto, se obtuvo %+v", created)
+	}
+
+	reports, err := getSavedReports("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Tickets != "PRJ-1,PRJ-2" {
+		t.Errorf("Reportes incorrectos, se obtuvo %+v", reports)
+	}
+
+	// The names are typed by the users, the page must not render them as HTML
+	recorder := httptest.NewRecorder()
+	getSavedReportsPageHandler(recorder, httptest.NewRequest("GET", "/saved-reports", nil))
+	page := recorder.Body.String()
+	if !strings.Contains(page, "Sprint &lt;12&gt;") || !strings.Contains(page, created.URL) {
+		t.Errorf("La página de reportes guardados no incluye el reporte: %s", page)
+	}
+}
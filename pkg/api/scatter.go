@@ -0,0 +1,51 @@
+package api
+
+import (
+	"sort"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+)
+
+// ScatterPoint is a ticket done in the cycle time scatterplot
+type ScatterPoint struct {
+	Date      string `json:"date"`       // Date the ticket was done, YYYY-MM-DD
+	CycleTime int    `json:"cycle_time"` // Days
+	Label     string `json:"label"`      // Custom ID of the ticket, or its ID if it has none
+	URL       string `json:"url"`
+}
+
+// ScatterData is the data of the cycle time scatterplot, with the percentiles drawn as lines
+type ScatterData struct {
+	ChartID    string         `json:"-"`
+	ChartLabel string         `json:"-"`
+	Points     []ScatterPoint `json:"points"`
+	P50        int            `json:"p50"`
+	P85        int            `json:"p85"`
+	P95        int            `json:"p95"`
+}
+
+// newCycleTimeScatter plots the cycle time of every ticket done against the date it was done,
+// the tickets not done yet are left out
+func newCycleTimeScatter(tasks []TaskMetricsResponse) ScatterData {
+	scatter := ScatterData{ChartID: "cycle-time-scatter-chart", ChartLabel: "Cycle Time", Points: []ScatterPoint{}}
+	cycleTimes := []int{}
+	for _, task := range tasks {
+		if task.DoneAt == "" {
+			continue
+		}
+		label := task.CustomId
+		if label == "" {
+			label = task.Id
+		}
+		scatter.Points = append(scatter.Points, ScatterPoint{Date: task.DoneAt, CycleTime: task.CycleTime, Label: label, URL: task.URL})
+		cycleTimes = append(cycleTimes, task.CycleTime)
+	}
+	sort.SliceStable(scatter.Points, func(i, j int) bool {
+		return scatter.Points[i].Date < scatter.Points[j].Date
+	})
+
+	scatter.P50 = metrics.Percentile(cycleTimes, 50)
+	scatter.P85 = metrics.Percentile(cycleTimes, 85)
+	scatter.P95 = metrics.Percentile(cycleTimes, 95)
+	return scatter
+}
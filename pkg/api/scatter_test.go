@@ -0,0 +1,26 @@
+package api
+
+import "testing"
+
+func TestNewCycleTimeScatter(t *testing.T) {
+	tasks := []TaskMetricsResponse{
+		{Id: "1", CustomId: "PRJ-1", CycleTime: 8, DoneAt: "2024-01-10", URL: "https://app.clickup.com/t/1"},
+		{Id: "2", CycleTime: 2, DoneAt: "2024-01-03"},
+		{Id: "3", CycleTime: 5},
+		{Id: "4", CycleTime: 4, DoneAt: "2024-01-05"},
+	}
+	scatter := newCycleTimeScatter(tasks)
+
+	if len(scatter.Points) != 3 {
+		t.Fatalf("Cantidad de puntos incorrecta, se esperaba %d pero se obtuvo %d", 3, len(scatter.Points))
+	}
+	if first := scatter.Points[0]; first.Date != "2024-01-03" || first.Label != "2" {
+		t.Errorf("Primer punto incorrecto, se obtuvo %+v", first)
+	}
+	if last := scatter.Points[2]; last.Label != "PRJ-1" || last.URL != "https://app.clickup.com/t/1" {
+		t.Errorf("Último punto incorrecto, se obtuvo %+v", last)
+	}
+	if scatter.P50 != 4 || scatter.P85 != 8 || scatter.P95 != 8 {
+		t.Errorf("Percentiles incorrectos, se obtuvo %d, %d y %d", scatter.P50, scatter.P85, scatter.P95)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/configuration"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+	"github.com/robfig/cron/v3"
+)
+
+// defaultCollectLookback is how far back the scheduled collection looks for updated tasks and merged merge requests
+const defaultCollectLookback = 90 * 24 * time.Hour
+
+// CollectConfig is what the scheduled collection saves in the metrics store
+type CollectConfig struct {
+	Lists    []string      // ClickUp lists whose tasks are collected
+	GroupIDs []string      // GitLab groups whose merge requests are collected
+	Lookback time.Duration // Tasks updated and merge requests merged within this time are collected
+}
+
+// scheduler runs the collection, it is nil when SCHEDULE is not set
+var scheduler *cron.Cron
+
+// configureScheduler starts collecting the configured lists and groups into the metrics store with the cron expression
+// of the SCHEDULE environment variable (e.g. "0 */6 * * *" or "@every 1h"), and once right away.
+// The lists and groups are read from SCHEDULE_LISTS and SCHEDULE_GITLAB_GROUPS, the time window from SCHEDULE_LOOKBACK.
+func configureScheduler() {
+	schedule := os.Getenv("SCHEDULE")
+	if schedule == "" {
+		return
+	}
+	if metricsStore == nil {
+		log.Fatal("Error: SCHEDULE requires STORE_PATH to save the collected snapshots")
+	}
+
+	config := CollectConfig{
+		Lists:    splitList(os.Getenv("SCHEDULE_LISTS")),
+		GroupIDs: splitList(os.Getenv("SCHEDULE_GITLAB_GROUPS")),
+		Lookback: getDurationEnv("SCHEDULE_LOOKBACK", defaultCollectLookback),
+	}
+
+	// A collection that takes longer than the schedule is not run twice at the same time
+	scheduler = cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
+	_, err := scheduler.AddFunc(schedule, func() { runCollect(config) })
+	if err != nil {
+		log.Fatalf("Error: SCHEDULE is not a valid cron expression: %v", err)
+	}
+
+	log.Println("Collecting metrics with schedule", schedule)
+	scheduler.Start()
+	go runCollect(config)
+}
+
+// runCollect collects the metrics and logs the result
+func runCollect(config CollectConfig) {
+	snapshotID, err := Collect(config, time.Now())
+	if err != nil {
+		log.Println("Error collecting metrics:", err)
+		return
+	}
+	log.Printf("Snapshot %d collected", snapshotID)
+}
+
+// Collect retrieves the tasks of the lists updated within the lookback and the merge requests of the groups
+// merged within it, and saves them as a new snapshot of the metrics store.
+// Nothing is saved if the tasks of a list can't be retrieved, so a snapshot never misses part of a list.
+func Collect(config CollectConfig, now time.Time) (int64, error) {
+	if err := configuration.LoadEnvironmentVariables(); err != nil {
+		return 0, err
+	}
+	return collect(metricsStore, liveDataSource(), gitlabMergeRequests(""), config, now)
+}
+
+func collect(store *storage.Store, source data.Data, getMergeRequests groupMergeRequests, config CollectConfig, now time.Time) (int64, error) {
+	since := now.Add(-config.Lookback)
+	tickets := []string{}
+	for _, listID := range config.Lists {
+		tasks, err := source.GetTasksWithFilter(data.Filter{ListID: listID, UpdatedAfter: since})
+		if err != nil {
+			return 0, fmt.Errorf("error retrieving the tasks of list %s: %w", listID, err)
+		}
+		for _, task := range tasks {
+			tickets = append(tickets, task.Id)
+		}
+	}
+
+	return ingest(store, source, getMergeRequests, IngestParams{
+		Tickets:     tickets,
+		StartDate:   since.Format("2006-01-02"),
+		EndDate:     now.Format("2006-01-02"),
+		GroupIDs:    config.GroupIDs,
+		Description: "Scheduled collection",
+	})
+}
+
+// splitList splits a comma separated list, skipping the blank items
+func splitList(list string) []string {
+	result := []string{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+)
+
+func TestSplitList(t *testing.T) {
+	result := splitList(" 901, ,902,")
+	expected := []string{"901", "902"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Lista incorrecta, se esperaba %v pero se obtuvo %v", expected, result)
+	}
+
+	if len(splitList("")) != 0 {
+		t.Errorf("Se esperaba una lista vacía")
+	}
+}
+
+// listSource returns the tasks of its lists, and an error for the lists it doesn't have
+type listSource struct {
+	lists map[string][]data.TaskHeaderData
+}
+
+func (l *listSource) GetTasksWithFilter(filter data.Filter) ([]data.TaskHeaderData, error) {
+	tasks, ok := l.lists[filter.ListID]
+	if !ok {
+		return nil, data.ErrRateLimited
+	}
+	return tasks, nil
+}
+
+func (l *listSource) GetTaskByID(id string) (*data.TaskInfo, error) {
+	for _, tasks := range l.lists {
+		for _, task := range tasks {
+			if task.Id == id {
+				return &data.TaskInfo{TaskHeaderData: task, History: []data.History{{Status: "in development", Time: 60}}}, nil
+			}
+		}
+	}
+	return nil, data.ErrNotFound
+}
+
+func (l *listSource) GetHistoryPerTask(ids []string) *map[string]data.TaskInfo { return nil }
+func (l *listSource) GetWorkflow() *data.Workflow                              { return nil }
+
+func TestCollect(t *testing.T) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	source := &listSource{lists: map[string][]data.TaskHeaderData{
+		"901": {{Id: "86a0x1", CustomId: "PRJ-1", ListID: "901"}, {Id: "86a0x2", CustomId: "PRJ-2", ListID: "901"}},
+	}}
+	groups := []string{}
+	getMergeRequests := func(groupID string, startDate string, endDate string) ([]mergerequests.MergeRequest, error) {
+		groups = append(groups, groupID+" "+startDate+" "+endDate)
+		return []mergerequests.MergeRequest{{ProjectID: 1, IID: len(groups), Title: "PRJ-1", MergedAt: "2024-03-01 10:00"}}, nil
+	}
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	config := CollectConfig{Lists: []string{"901"}, GroupIDs: []string{"10", "20"}, Lookback: 30 * 24 * time.Hour}
+
+	if _, err := collect(store, source, getMergeRequests, config, now); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedGroups := []string{"10 2024-03-01 2024-03-31", "20 2024-03-01 2024-03-31"}
+	if !reflect.DeepEqual(groups, expectedGroups) {
+		t.Errorf("Grupos incorrectos, se esperaba %v pero se obtuvo %v", expectedGroups, groups)
+	}
+	tasks, err := store.GetLatestTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Cantidad de tareas incorrecta, se esperaba %d pero se obtuvo %d", 2, len(tasks))
+	}
+	mrs, err := store.GetLatestMergeRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mrs) != 2 {
+		t.Errorf("Cantidad de merge requests incorrecta, se esperaba %d pero se obtuvo %d", 2, len(mrs))
+	}
+
+	// A list that can't be retrieved aborts the collection without saving a partial snapshot
+	config.Lists = []string{"901", "902"}
+	_, err = collect(store, source, getMergeRequests, config, now)
+	if !errors.Is(err, data.ErrRateLimited) {
+		t.Errorf("Se esperaba ErrRateLimited pero se obtuvo %v", err)
+	}
+	snapshots, err := store.GetSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Errorf("Cantidad de snapshots incorrecta, se esperaba %d pero se obtuvo %d", 1, len(snapshots))
+	}
+}
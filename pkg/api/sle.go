@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+// sleRiskRatio is the share of the days of the SLE after which a task in progress is about to breach it
+const sleRiskRatio = 0.7
+
+// SLEReport is the compliance of the service level expectation of a team: the share of the tasks done between the
+// dates within its days, overall and per period, and the tasks in progress about to breach it or already breaching it
+type SLEReport struct {
+	Team       string      `json:"team"`
+	Percentile float64     `json:"percentile"`
+	Days       int         `json:"days"`
+	Done       int         `json:"done"`       // Tasks done between the dates
+	WithinSLE  int         `json:"within_sle"` // Tasks done between the dates within the days of the SLE
+	Compliance float64     `json:"compliance"` // Percentage of the tasks done within the days of the SLE
+	Met        bool        `json:"met"`        // Whether the compliance reaches the percentile
+	Periods    []SLEPeriod `json:"periods"`    // Compliance in every period of the dates
+	AtRisk     []AgingTask `json:"at_risk"`    // Tasks in progress, the oldest first
+}
+
+// SLEPeriod is the compliance of the SLE of the tasks done in a period
+type SLEPeriod struct {
+	StartDate  string  `json:"start_date"`
+	EndDate    string  `json:"end_date"`
+	Done       int     `json:"done"`
+	WithinSLE  int     `json:"within_sle"`
+	Compliance float64 `json:"compliance"`
+}
+
+// AgingTask is a task in progress whose age, its cycle time so far, is close to the days of the SLE or above them
+type AgingTask struct {
+	Id       string `json:"id"`
+	CustomId string `json:"custom_id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	URL      string `json:"url"`
+	Age      int    `json:"age"`       // Days
+	DaysLeft int    `json:"days_left"` // Days until the SLE is breached, negative once breached
+	Breached bool   `json:"breached"`
+}
+
+// newSLEReport computes the compliance of the SLE with the tasks of the team. The tasks done between the dates
+// count for the compliance, split into the periods of the params, and the tasks in progress are checked for breaches.
+func newSLEReport(team string, sle teams.SLE, tasks []TaskMetricsResponse, params DashboardParams) (*SLEReport, error) {
+	report := &SLEReport{Team: team, Percentile: sle.Percentile, Days: sle.Days, Periods: []SLEPeriod{}, AtRisk: []AgingTask{}}
+	if params.StartDate != "" && params.EndDate != "" {
+		periods, err := splitPeriods(params.StartDate, params.EndDate, periodDays(params))
+		if err != nil {
+			return nil, err
+		}
+		for _, period := range periods {
+			report.Periods = append(report.Periods, SLEPeriod{StartDate: period.StartDate, EndDate: period.EndDate})
+		}
+	}
+
+	for _, task := range tasks {
+		if task.InProgress && float64(task.CycleTime) >= sleRiskRatio*float64(sle.Days) {
+			report.AtRisk = append(report.AtRisk, AgingTask{
+				Id:       task.Id,
+				CustomId: task.CustomId,
+				Name:     task.Name,
+				Status:   task.Status,
+				URL:      task.URL,
+				Age:      task.CycleTime,
+				DaysLeft: sle.Days - task.CycleTime,
+				Breached: task.CycleTime > sle.Days,
+			})
+		}
+		if task.DoneAt == "" || task.DoneAt < params.StartDate || (params.EndDate != "" && task.DoneAt > params.EndDate) {
+			continue
+		}
+		within := task.CycleTime <= sle.Days
+		report.Done++
+		if within {
+			report.WithinSLE++
+		}
+		for i := range report.Periods {
+			period := &report.Periods[i]
+			if task.DoneAt >= period.StartDate && task.DoneAt <= period.EndDate {
+				period.Done++
+				if within {
+					period.WithinSLE++
+				}
+			}
+		}
+	}
+
+	report.Compliance = compliance(report.WithinSLE, report.Done)
+	report.Met = report.Done > 0 && report.Compliance >= sle.Percentile
+	for i := range report.Periods {
+		report.Periods[i].Compliance = compliance(report.Periods[i].WithinSLE, report.Periods[i].Done)
+	}
+	sort.SliceStable(report.AtRisk, func(i, j int) bool {
+		return report.AtRisk[i].Age > report.AtRisk[j].Age
+	})
+	return report, nil
+}
+
+// compliance returns the percentage of the done tasks within the SLE, 0 without done tasks
+func compliance(within int, done int) float64 {
+	if done == 0 {
+		return 0
+	}
+	return float64(within) * 100 / float64(done)
+}
+
+// getSLEData fills the SLE report of the dashboard, if the team of the params has an SLE
+func getSLEData(result *DashboardData, params DashboardParams) error {
+	if params.team == nil || params.team.SLE == nil {
+		return nil
+	}
+	report, err := newSLEReport(params.team.ID, *params.team.SLE, result.TaskMetrics, params)
+	if err != nil {
+		return err
+	}
+	result.SLE = report
+	result.SLEComplianceData = ChartData{ChartID: "sle-compliance-chart", ChartLabel: "SLE %"}
+	for _, period := range report.Periods {
+		result.SLEComplianceData.Labels = append(result.SLEComplianceData.Labels, period.StartDate)
+		result.SLEComplianceData.Data = append(result.SLEComplianceData.Data, int(period.Compliance))
+	}
+	return nil
+}
+
+// getTeamSLEHandler is the handler function for the GET /api/teams/{team_id}/sle endpoint.
+// It returns the compliance of the SLE of the team with the tasks of its lists between start_date and end_date.
+func getTeamSLEHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params, err := parseDashboardParams(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params.Team, params.Tickets = mux.Vars(r)["team_id"], ""
+	if params.StartDate == "" || params.EndDate == "" {
+		writeError(w, r, newBadRequestError("error.dates_required"))
+		return
+	}
+
+	params, err = applyTeam(params)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if params.team.SLE == nil {
+		writeError(w, r, newBadRequestError("error.sle_not_configured", params.Team))
+		return
+	}
+	result := &DashboardData{}
+	if err := getClickUpData(result, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	report, err := newSLEReport(params.Team, *params.team.SLE, result.TaskMetrics, params)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+func TestNewSLEReport(t *testing.T) {
+	tasks := []TaskMetricsResponse{
+		{Id: "1", CycleTime: 4, DoneAt: "2024-01-03"},
+		{Id: "2", CycleTime: 12, DoneAt: "2024-01-05"},
+		{Id: "3", CycleTime: 8, DoneAt: "2024-01-16"},
+		{Id: "4", CycleTime: 8, InProgress: true},
+		{Id: "5", CycleTime: 12, InProgress: true},
+		{Id: "6", CycleTime: 2, InProgress: true},
+	}
+	params := DashboardParams{StartDate: "2024-01-01", EndDate: "2024-01-28", Period: PeriodWeek}
+	report, err := newSLEReport("core", teams.SLE{Percentile: 85, Days: 10}, tasks, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Done != 3 || report.WithinSLE != 2 || report.Met {
+		t.Errorf("Cumplimiento incorrecto, se obtuvo %+v", report)
+	}
+	if len(report.Periods) != 4 {
+		t.Fatalf("Cantidad de períodos incorrecta, se esperaba %d pero se obtuvo %d", 4, len(report.Periods))
+	}
+	if report.Periods[0].Compliance != 50 || report.Periods[2].Compliance != 100 || report.Periods[3].Done != 0 {
+		t.Errorf("Cumplimiento por período incorrecto, se obtuvo %+v", report.Periods)
+	}
+	if len(report.AtRisk) != 2 {
+		t.Fatalf("Cantidad de tickets en riesgo incorrecta, se esperaba %d pero se obtuvo %d", 2, len(report.AtRisk))
+	}
+	if oldest := report.AtRisk[0]; oldest.Id != "5" || !oldest.Breached || oldest.DaysLeft != -2 {
+		t.Errorf("Ticket incumplido incorrecto, se obtuvo %+v", oldest)
+	}
+	if atRisk := report.AtRisk[1]; atRisk.Id != "4" || atRisk.Breached || atRisk.DaysLeft != 2 {
+		t.Errorf("Ticket en riesgo incorrecto, se obtuvo %+v", atRisk)
+	}
+}
+
+func TestGetTeamSLEHandler(t *testing.T) {
+	t.Setenv("API_KEY", "test")
+	store, err := storage.Open(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricsStore = store
+	registry, err := teams.Parse(strings.NewReader(`[
+		{"id": "core", "clickup_lists": ["901"], "sle": {"percentile": 85, "days": 4}},
+		{"id": "prga", "clickup_lists": ["902"]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamRegistry = registry
+	defer func() { metricsStore, teamRegistry = nil, nil }()
+
+	// 2024-01-10 and 2024-01-20, in milliseconds
+	tasks := []data.TaskInfo{
+		newListTask("1", "901", "completed", 3*24*60, "1704880800000"),
+		newListTask("2", "901", "completed", 5*24*60, "1705744800000"),
+		newListTask("3", "901", "in development", 3*24*60, "1705744800000"),
+	}
+	if _, err := store.SaveSnapshot(storage.Snapshot{Tasks: tasks}); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(teamID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/teams/"+teamID+"/sle?start_date=2024-01-01&end_date=2024-01-31&source=store", nil)
+		recorder := httptest.NewRecorder()
+		getTeamSLEHandler(recorder, mux.SetURLVars(r, map[string]string{"team_id": teamID}))
+		return recorder
+	}
+
+	recorder := request("core")
+	if recorder.Code != 200 {
+		t.Fatalf("Código incorrecto, se esperaba %d pero se obtuvo %d: %s", 200, recorder.Code, recorder.Body)
+	}
+	var report SLEReport
+	if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Done != 2 || report.WithinSLE != 1 || report.Compliance != 50 {
+		t.Errorf("Cumplimiento incorrecto, se obtuvo %+v", report)
+	}
+	if len(report.AtRisk) != 1 || report.AtRisk[0].Id != "3" {
+		t.Errorf("Tickets en riesgo incorrectos, se obtuvo %+v", report.AtRisk)
+	}
+
+	if code := request("prga").Code; code != 400 {
+		t.Errorf("Código incorrecto para un equipo sin SLE, se esperaba %d pero se obtuvo %d", 400, code)
+	}
+	if code := request("missing").Code; code != 404 {
+		t.Errorf("Código incorrecto para un equipo desconocido, se esperaba %d pero se obtuvo %d", 404, code)
+	}
+}
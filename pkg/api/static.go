@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Formats of GET /dashboard/export
+const (
+	StaticFormatHTML = "html"
+	StaticFormatPDF  = "pdf"
+)
+
+const pdfTimeout = 2 * time.Minute
+
+var (
+	stylesheetPattern = regexp.MustCompile(`<link[^>]*href="([^"]+)"[^>]*rel="stylesheet"[^>]*>|<link[^>]*rel="stylesheet"[^>]*href="([^"]+)"[^>]*>`)
+	scriptPattern     = regexp.MustCompile(`<script[^>]*src="([^"]+)"[^>]*>\s*</script>`)
+
+	errPDFUnavailable = newAPIError(http.StatusNotImplemented, ErrorCodeNotImplemented, "error.pdf_unavailable")
+)
+
+// getStaticReportHandler is the handler function for the GET /dashboard/export endpoint.
+// It returns the dashboard as a self-contained HTML file, or as a PDF with format=pdf.
+func getStaticReportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = StaticFormatHTML
+	}
+	if format != StaticFormatHTML && format != StaticFormatPDF {
+		writeError(w, r, newBadRequestError("error.invalid_format"))
+		return
+	}
+
+	params, err := parseDashboardParams(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	data, err := getDashboardData(params)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// The file is built before writing it, so errors can still be reported with their status
+	var buf bytes.Buffer
+	if format == StaticFormatPDF {
+		err = RenderPDFReport(&buf, data)
+	} else {
+		err = RenderStaticReport(&buf, data)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	filename := "report"
+	if params.StartDate != "" && params.EndDate != "" {
+		filename = fmt.Sprintf("report_%s_%s", params.StartDate, params.EndDate)
+	}
+	if format == StaticFormatPDF {
+		w.Header().Set("Content-Type", "application/pdf")
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// RenderStaticReport renders the dashboard of data as a single HTML file, with its CSS and JS inlined,
+// that can be opened without access to the service
+func RenderStaticReport(w io.Writer, data *DashboardData) error {
+	tmpl, err := getDashboardTemplates(data.Lang)
+	if err != nil {
+		return err
+	}
+
+	static := *data
+	static.Static = true
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, &static); err != nil {
+		return err
+	}
+
+	html, err := inlineAssets(buf.String())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, html)
+	return err
+}
+
+// RenderPDFReport prints the static report of data as a PDF with headless Chrome or Chromium
+func RenderPDFReport(w io.Writer, data *DashboardData) error {
+	chrome := findChrome()
+	if chrome == "" {
+		return errPDFUnavailable
+	}
+
+	dir, err := os.MkdirTemp("", "clickup-metrics-report")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	htmlPath := filepath.Join(dir, "report.html")
+	pdfPath := filepath.Join(dir, "report.pdf")
+
+	htmlFile, err := os.Create(htmlPath)
+	if err != nil {
+		return err
+	}
+	err = RenderStaticReport(htmlFile, data)
+	htmlFile.Close()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pdfTimeout)
+	defer cancel()
+
+	// The charts are drawn by JS, Chrome waits for them before printing. The report has the titles of the tickets
+	// and merge requests, so it is rendered with the sandbox of Chrome, which doesn't start as root.
+	cmd := exec.CommandContext(ctx, chrome, "--headless", "--disable-gpu",
+		"--no-pdf-header-footer", "--virtual-time-budget=10000", "--print-to-pdf="+pdfPath, "file://"+htmlPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error printing the report to PDF: %v: %s", err, output)
+	}
+
+	pdf, err := os.Open(pdfPath)
+	if err != nil {
+		return err
+	}
+	defer pdf.Close()
+
+	_, err = io.Copy(w, pdf)
+	return err
+}
+
+// findChrome returns the path of the CHROME_PATH environment variable or of the first Chrome or Chromium binary found
+func findChrome() string {
+	if path := os.Getenv("CHROME_PATH"); path != "" {
+		return path
+	}
+	for _, name := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// inlineAssets replaces the stylesheets and scripts linked by the HTML with their content
+func inlineAssets(html string) (string, error) {
+	var err error
+
+	html = stylesheetPattern.ReplaceAllStringFunc(html, func(tag string) string {
+		match := stylesheetPattern.FindStringSubmatch(tag)
+		content, assetErr := getAsset(match[1] + match[2])
+		if assetErr != nil {
+			err = assetErr
+			return tag
+		}
+		return "<style>\n" + string(content) + "\n</style>"
+	})
+	if err != nil {
+		return "", err
+	}
+
+	html = scriptPattern.ReplaceAllStringFunc(html, func(tag string) string {
+		match := scriptPattern.FindStringSubmatch(tag)
+		content, assetErr := getAsset(match[1])
+		if assetErr != nil {
+			err = assetErr
+			return tag
+		}
+		// A closing tag inside the script would end it early
+		return "<script>\n" + strings.ReplaceAll(string(content), "</script", "<\\/script") + "\n</script>"
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return html, nil
+}
+
+// getAsset returns the content of a file of the static folder, the libraries are vendored there
+// so the reports are rendered without downloading anything
+func getAsset(url string) ([]byte, error) {
+	if !strings.HasPrefix(url, "/static/") {
+		return nil, fmt.Errorf("%s is not a static file, only the files of web/static can be inlined", url)
+	}
+	name, _ := resolveAsset(strings.TrimPrefix(url, "/static/"))
+	return fs.ReadFile(staticFiles(), name)
+}
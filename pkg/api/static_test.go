@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/web"
+)
+
+func TestInlineAssets(t *testing.T) {
+	styles, err := fs.ReadFile(staticFiles(), "styles.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html := `<head>
+    <link href="` + assetURL("styles.css") + `" rel="stylesheet">
+    <script src="/static/lib/jquery-3.6.1/jquery.min.js"></script>
+</head>`
+
+	result, err := inlineAssets(html)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "<style>\n"+string(styles)+"\n</style>") {
+		t.Errorf("No se incluyó el CSS, se obtuvo:\n%s", result)
+	}
+	if !strings.Contains(result, "<script>\n/*! jQuery v3.6.1") {
+		t.Errorf("No se incluyó el JS")
+	}
+	if strings.Contains(result, "/static/") {
+		t.Errorf("Quedaron referencias a archivos externos")
+	}
+
+	_, err = inlineAssets(`<script src="https://cdn.example.com/lib.js"></script>`)
+	if err == nil {
+		t.Errorf("Se esperaba un error por el archivo externo")
+	}
+	_, err = inlineAssets(`<script src="/static/missing.js"></script>`)
+	if err == nil {
+		t.Errorf("Se esperaba un error por el archivo inexistente")
+	}
+}
+
+func TestRenderStaticReport(t *testing.T) {
+	data := &DashboardData{StartDate: "2024-01-01", EndDate: "2024-01-31", TaskMetrics: []TaskMetricsResponse{{Id: "1", CustomId: "PRJ-1"}}}
+	var buf bytes.Buffer
+	if err := RenderStaticReport(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), "/static/") || strings.Contains(buf.String(), "<script src=") {
+		t.Errorf("Quedaron referencias a archivos externos en el reporte")
+	}
+	for _, asset := range web.Vendored {
+		content, err := fs.ReadFile(staticFiles(), asset.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The scripts are inlined with their closing tags escaped
+		inlined := strings.ReplaceAll(string(content), "</script", "<\\/script")
+		if !strings.Contains(buf.String(), inlined) {
+			t.Errorf("No se incluyó %s en el reporte", asset.Path)
+		}
+	}
+}
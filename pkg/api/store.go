@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/configuration"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/storage"
+)
+
+// Sources of the tasks and merge requests, selected with the source query param
+const (
+	SourceLive  = "live"  // ClickUp and GitLab APIs
+	SourceStore = "store" // Snapshots saved in the metrics store
+)
+
+// metricsStore is the SQLite database with the ingested snapshots, it is nil when STORE_PATH is not set
+var metricsStore *storage.Store
+
+// mergeRequestSource is implemented by the GitLab client and the metrics store
+type mergeRequestSource interface {
+	GetMergeRequestsBetween(startDate string, endDate string, dateField mergerequests.DateField) ([]mergerequests.MergeRequest, error)
+}
+
+// IngestParams are the tasks and merge requests saved by Ingest
+type IngestParams struct {
+	Tickets     []string
+	StartDate   string
+	EndDate     string
+	Prefix      string
+	GroupIDs    []string // GitLab groups of the merge requests, the default group if empty
+	Description string
+}
+
+// configureStore opens the metrics store at the STORE_PATH environment variable, if it is set
+func configureStore() {
+	path := os.Getenv("STORE_PATH")
+	if path == "" {
+		return
+	}
+
+	store, err := storage.Open(path)
+	if err != nil {
+		log.Fatal("Error opening metrics store:", err)
+	}
+	log.Println("Reading snapshots from", path)
+	metricsStore = store
+}
+
+// getSource returns the source requested with the source query param, the live APIs by default
+func getSource(r *http.Request) string {
+	if r.URL.Query().Get("source") == SourceStore {
+		return SourceStore
+	}
+	return SourceLive
+}
+
+// getSnapshot returns the snapshot requested with the snapshot query param, or 0 for the latest one.
+// Only the store has snapshots, so it can't be combined with the live source.
+func getSnapshot(r *http.Request) (int64, error) {
+	param := r.URL.Query().Get("snapshot")
+	if param == "" {
+		return 0, nil
+	}
+
+	snapshotID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil || snapshotID <= 0 {
+		return 0, newBadRequestError("error.invalid_snapshot")
+	}
+	if getSource(r) != SourceStore {
+		return 0, newBadRequestError("error.snapshot_requires_store")
+	}
+	return snapshotID, nil
+}
+
+// storeAsOf returns the metrics store as it was when the snapshot was taken, or with every snapshot if snapshotID is 0
+func storeAsOf(snapshotID int64) (*storage.Store, error) {
+	if metricsStore == nil {
+		return nil, errStoreNotConfigured
+	}
+	if snapshotID == 0 {
+		return metricsStore, nil
+	}
+	if _, err := metricsStore.GetSnapshot(snapshotID); err != nil {
+		return nil, err
+	}
+	return metricsStore.AsOf(snapshotID), nil
+}
+
+// newMergeRequestSource returns the metrics store as of the snapshot or a GitLab client, depending on source
+func newMergeRequestSource(prefix string, source string, snapshotID int64, refresh bool) (mergeRequestSource, error) {
+	if source == SourceStore {
+		store, err := storeAsOf(snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		return store.MergeRequests(prefix), nil
+	}
+
+	cli, err := newGitlabClient(prefix)
+	if err != nil {
+		return nil, err
+	}
+	cli.Refresh = refresh
+	return cli, nil
+}
+
+var errStoreNotConfigured = newNotConfiguredError("error.store_not_configured")
+
+// groupMergeRequests retrieves the merge requests of a GitLab group (the default group if empty) merged between the dates
+type groupMergeRequests func(groupID string, startDate string, endDate string) ([]mergerequests.MergeRequest, error)
+
+// gitlabMergeRequests retrieves the merge requests whose title contains prefix from GitLab
+func gitlabMergeRequests(prefix string) groupMergeRequests {
+	return func(groupID string, startDate string, endDate string) ([]mergerequests.MergeRequest, error) {
+		return GetMergeRequests(groupID, prefix, startDate, endDate, mergerequests.DateFieldMerged)
+	}
+}
+
+// Ingest retrieves the tickets from ClickUp and the merge requests merged between the dates from GitLab
+// and saves them as a new snapshot of the store.
+// Tickets that can't be retrieved are skipped, unless the error affects every ticket.
+// It doesn't change the data source of the API, so it can run while requests are served.
+func Ingest(store *storage.Store, params IngestParams) (int64, error) {
+	if err := configuration.LoadEnvironmentVariables(); err != nil {
+		return 0, err
+	}
+	return ingest(store, liveDataSource(), gitlabMergeRequests(params.Prefix), params)
+}
+
+// ingest saves the tickets of source and the merge requests of the groups as a new snapshot of the store
+func ingest(store *storage.Store, source data.Data, getMergeRequests groupMergeRequests, params IngestParams) (int64, error) {
+	snapshot := storage.Snapshot{Description: params.Description}
+
+	for _, ticketID := range normalizeTaskIDs(params.Tickets) {
+		taskInfo, err := source.GetTaskByID(ticketID)
+		if errors.Is(err, data.ErrUnauthorized) || errors.Is(err, data.ErrRateLimited) {
+			return 0, err
+		}
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		snapshot.Tasks = append(snapshot.Tasks, *taskInfo)
+	}
+
+	if params.StartDate != "" && params.EndDate != "" {
+		groupIDs := params.GroupIDs
+		if len(groupIDs) == 0 {
+			groupIDs = []string{""}
+		}
+		for _, groupID := range groupIDs {
+			mrs, err := getMergeRequests(groupID, params.StartDate, params.EndDate)
+			if err != nil {
+				return 0, fmt.Errorf("error retrieving merge requests of group %s: %w", groupID, err)
+			}
+			snapshot.MergeRequests = append(snapshot.MergeRequests, mrs...)
+		}
+	}
+
+	return store.SaveSnapshot(snapshot)
+}
+
+// SnapshotResponse is a snapshot of the store, without its data
+type SnapshotResponse struct {
+	ID          int64  `json:"id"`
+	TakenAt     string `json:"taken_at"`
+	Description string `json:"description"`
+}
+
+// getSnapshotsHandler is the handler function for the GET /api/snapshots endpoint.
+// It lists the snapshots of the store, most recent first, so one of them can be requested with the snapshot param.
+func getSnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if metricsStore == nil {
+		writeError(w, r, errStoreNotConfigured)
+		return
+	}
+	snapshots, err := metricsStore.GetSnapshots()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := []SnapshotResponse{}
+	for _, snapshot := range snapshots {
+		response = append(response, SnapshotResponse{
+			ID:          snapshot.ID,
+			TakenAt:     snapshot.TakenAt.Format(time.RFC3339),
+			Description: snapshot.Description,
+		})
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
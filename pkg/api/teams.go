@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+// teamRegistry has the teams of the TEAMS_FILE environment variable, it has no teams when it is not set
+var teamRegistry *teams.Registry
+
+// configureTeams reads the teams from the JSON file at the TEAMS_FILE environment variable, if it is set
+func configureTeams() {
+	path := os.Getenv("TEAMS_FILE")
+	if path == "" {
+		return
+	}
+
+	registry, err := teams.Load(path)
+	if err != nil {
+		log.Fatal("Error reading teams: ", err)
+	}
+	log.Printf("Read %d teams from %s", len(registry.Teams()), path)
+	teamRegistry = registry
+}
+
+// applyTeam fills the filters left empty with the configuration of the team of the params, if one was selected
+func applyTeam(params DashboardParams) (DashboardParams, error) {
+	if params.Team == "" {
+		return params, nil
+	}
+	team, err := teamRegistry.Get(params.Team)
+	if err != nil {
+		return params, err
+	}
+
+	if params.Prefix == "" {
+		params.Prefix = team.Prefix
+	}
+	if params.Projects == "" {
+		params.Projects = team.ProjectList()
+	}
+	params.team = &team
+	return params, nil
+}
+
+// teamWorkflow returns the workflow with the statuses of the workflow profile and the holidays of the team
+func teamWorkflow(wf metrics.Workflow, team teams.Team) metrics.Workflow {
+	profile := team.Workflow
+	result := metrics.Workflow{Statuses: map[string]metrics.Status{}}
+	for name, status := range wf.Statuses {
+		result.Statuses[name] = status
+	}
+	// The statuses of the profile may not be in the default workflow
+	for _, name := range append(profile.CycleTimeStatuses, profile.BlockedStatuses...) {
+		if _, ok := result.Statuses[name]; !ok {
+			result.Statuses[name] = metrics.Status{Name: name, IsLeadTimeCalculable: true}
+		}
+	}
+
+	if len(profile.CycleTimeStatuses) > 0 {
+		setStatusesForCycleTimeCalculation(&result.Statuses, profile.CycleTimeStatuses...)
+	}
+	if len(profile.BlockedStatuses) > 0 {
+		for name, status := range result.Statuses {
+			status.Blocked = contains(profile.BlockedStatuses, name)
+			status.Pending = false
+			result.Statuses[name] = status
+		}
+	}
+
+	// The holidays are validated when the teams are read
+	result.Holidays, _ = team.HolidayDates()
+	return result
+}
+
+// teamTickets returns the IDs of the tasks of the lists of the team updated since startDate, separated by commas.
+// Without startDate, the tasks updated within the lookback of the scheduled collection are returned.
+func teamTickets(source data.Data, team teams.Team, startDate string) (string, error) {
+	since := time.Now().Add(-defaultCollectLookback)
+	if startDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
+		if err != nil {
+			return "", newBadRequestError("error.invalid_report_dates")
+		}
+		since = date
+	}
+
+	ids := []string{}
+	for _, listID := range team.Lists {
+		tasks, err := source.GetTasksWithFilter(data.Filter{ListID: listID, UpdatedAfter: since})
+		if err != nil {
+			return "", fmt.Errorf("error retrieving the tasks of list %s: %w", listID, err)
+		}
+		for _, task := range tasks {
+			ids = append(ids, task.Id)
+		}
+	}
+	return strings.Join(ids, ","), nil
+}
+
+// getTeamsHandler is the handler function for the GET /api/teams endpoint.
+// It lists the configured teams, in the order of TEAMS_FILE.
+func getTeamsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(teamRegistry.Teams()); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+// getTeamHandler is the handler function for the GET /api/teams/{team_id} endpoint
+func getTeamHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	team, err := teamRegistry.Get(mux.Vars(r)["team_id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(team); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data/clickup"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+func TestApplyTeam(t *testing.T) {
+	registry, err := teams.Parse(strings.NewReader(`[{"id": "core", "gitlab_projects": [123, 456], "prefix": "CORE"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamRegistry = registry
+	defer func() { teamRegistry = nil }()
+
+	params, err := applyTeam(DashboardParams{Team: "core"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Prefix != "CORE" || params.Projects != "123,456" || params.team == nil {
+		t.Errorf("Filtros del equipo incorrectos, se obtuvo %+v", params)
+	}
+
+	// The filters of the request win over the ones of the team
+	params, _ = applyTeam(DashboardParams{Team: "core", Prefix: "PRGA"})
+	if params.Prefix != "PRGA" {
+		t.Errorf("Prefijo incorrecto, se esperaba %s pero se obtuvo %s", "PRGA", params.Prefix)
+	}
+
+	if _, err := applyTeam(DashboardParams{Team: "missing"}); !errors.Is(err, teams.ErrTeamNotFound) {
+		t.Errorf("Se esperaba ErrTeamNotFound pero se obtuvo %v", err)
+	}
+}
+
+func TestTeamWorkflow(t *testing.T) {
+	team := teams.Team{
+		Workflow: teams.Workflow{CycleTimeStatuses: []string{clickup.StatusInDevelopment, "pair review"}, BlockedStatuses: []string{"waiting"}},
+		Holidays: []string{"2024-12-25"},
+	}
+	wf := teamWorkflow(createWorkflow(&clickup.Session{}), team)
+
+	if !wf.Statuses["pair review"].IsCycleTimeCalculable || !wf.Statuses[clickup.StatusInDevelopment].IsCycleTimeCalculable {
+		t.Errorf("Los estados del perfil deberían sumar al cycle time")
+	}
+	if wf.Statuses[clickup.StatusInTesting].IsCycleTimeCalculable {
+		t.Errorf("Los estados fuera del perfil no deberían sumar al cycle time")
+	}
+	if !wf.Statuses["waiting"].Blocked || wf.Statuses[clickup.StatusReadyForDev].Pending {
+		t.Errorf("Solo los estados bloqueados del perfil deberían sumar al blocked time")
+	}
+	if len(wf.Holidays) != 1 {
+		t.Errorf("Cantidad de feriados incorrecta, se esperaba %d pero se obtuvo %d", 1, len(wf.Holidays))
+	}
+}
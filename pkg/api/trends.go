@@ -0,0 +1,158 @@
+package api
+
+import (
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+)
+
+// Periods the date range of the dashboard can be split into, selected with the period query param
+const (
+	PeriodWeek   = "week"
+	PeriodSprint = "sprint"
+)
+
+const (
+	defaultSprintDays = 14
+	maxPeriods        = 104 // Two years of weeks
+)
+
+// PeriodMetrics are the metrics of the tickets done and the merge requests merged in a period of the date range
+type PeriodMetrics struct {
+	StartDate         string  `json:"start_date"`
+	EndDate           string  `json:"end_date"`
+	Throughput        int     `json:"throughput"` // Tickets done in the period
+	AvgLeadTime       int     `json:"avg_lead_time"`
+	AvgCycleTime      int     `json:"avg_cycle_time"`
+	AvgBlockedTime    int     `json:"avg_blocked_time"`
+	AvgFlowEfficiency float64 `json:"avg_flow_efficiency"`
+	CycleTimeP50      int     `json:"cycle_time_p50"`
+	CycleTimeP85      int     `json:"cycle_time_p85"`
+	MergeRequests     int     `json:"merge_requests"` // Merge requests merged in the period
+	TimeToMergeP50    int     `json:"time_to_merge_p50"`
+	TimeToMergeP85    int     `json:"time_to_merge_p85"`
+}
+
+// TrendDeltas are the differences between the metrics of the last period and the ones of the previous period
+type TrendDeltas struct {
+	LeadTime       int     `json:"lead_time"`
+	CycleTime      int     `json:"cycle_time"`
+	BlockedTime    int     `json:"blocked_time"`
+	FlowEfficiency float64 `json:"flow_efficiency"`
+}
+
+// isValidPeriod returns whether period can split the date range, an empty period doesn't split it
+func isValidPeriod(period string) bool {
+	return period == "" || period == PeriodWeek || period == PeriodSprint
+}
+
+// periodDays returns the length of the periods: a week, or the sprint of the team of the params
+func periodDays(params DashboardParams) int {
+	if params.Period == PeriodWeek {
+		return 7
+	}
+	if params.team != nil && params.team.SprintDays > 0 {
+		return params.team.SprintDays
+	}
+	return defaultSprintDays
+}
+
+// splitPeriods splits the dates into consecutive periods of days, starting at startDate.
+// The last period ends at endDate, so it can be shorter.
+func splitPeriods(startDate string, endDate string, days int) ([]PeriodMetrics, error) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, newBadRequestError("error.invalid_report_dates")
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil || end.Before(start) {
+		return nil, newBadRequestError("error.invalid_report_dates")
+	}
+
+	periods := []PeriodMetrics{}
+	for from := start; !from.After(end); from = from.AddDate(0, 0, days) {
+		if len(periods) == maxPeriods {
+			return nil, newBadRequestError("error.too_many_periods", maxPeriods)
+		}
+		to := from.AddDate(0, 0, days-1)
+		if to.After(end) {
+			to = end
+		}
+		periods = append(periods, PeriodMetrics{StartDate: from.Format("2006-01-02"), EndDate: to.Format("2006-01-02")})
+	}
+	return periods, nil
+}
+
+// getTrendData splits the date range of the dashboard into the periods of the params and fills the metrics of every
+// period, the charts of their evolution and the deltas of the last period
+func getTrendData(result *DashboardData, params DashboardParams) error {
+	if params.Period == "" || params.StartDate == "" || params.EndDate == "" {
+		return nil
+	}
+	periods, err := splitPeriods(params.StartDate, params.EndDate, periodDays(params))
+	if err != nil {
+		return err
+	}
+
+	for i := range periods {
+		fillPeriodMetrics(&periods[i], result)
+	}
+	result.Trends = periods
+
+	result.TrendCycleTimeData = ChartData{ChartID: "trend-cycle-time-chart", ChartLabel: "Cycle Time P85"}
+	result.TrendThroughputData = ChartData{ChartID: "trend-throughput-chart", ChartLabel: "Throughput"}
+	for _, period := range periods {
+		result.TrendCycleTimeData.Labels = append(result.TrendCycleTimeData.Labels, period.StartDate)
+		result.TrendCycleTimeData.Data = append(result.TrendCycleTimeData.Data, period.CycleTimeP85)
+		result.TrendThroughputData.Labels = append(result.TrendThroughputData.Labels, period.StartDate)
+		result.TrendThroughputData.Data = append(result.TrendThroughputData.Data, period.Throughput)
+	}
+
+	if len(periods) > 1 {
+		last, previous := periods[len(periods)-1], periods[len(periods)-2]
+		result.Deltas = &TrendDeltas{
+			LeadTime:       last.AvgLeadTime - previous.AvgLeadTime,
+			CycleTime:      last.AvgCycleTime - previous.AvgCycleTime,
+			BlockedTime:    last.AvgBlockedTime - previous.AvgBlockedTime,
+			FlowEfficiency: last.AvgFlowEfficiency - previous.AvgFlowEfficiency,
+		}
+	}
+	return nil
+}
+
+// fillPeriodMetrics computes the metrics of the tickets done and the merge requests merged in the period
+func fillPeriodMetrics(period *PeriodMetrics, result *DashboardData) {
+	cycleTimes := []int{}
+	for _, task := range result.TaskMetrics {
+		if task.DoneAt == "" || task.DoneAt < period.StartDate || task.DoneAt > period.EndDate {
+			continue
+		}
+		cycleTimes = append(cycleTimes, task.CycleTime)
+		period.AvgLeadTime += task.LeadTime
+		period.AvgCycleTime += task.CycleTime
+		period.AvgBlockedTime += task.BlockedTime
+		period.AvgFlowEfficiency += task.FlowEfficiency
+	}
+	if period.Throughput = len(cycleTimes); period.Throughput > 0 {
+		period.AvgLeadTime /= period.Throughput
+		period.AvgCycleTime /= period.Throughput
+		period.AvgBlockedTime /= period.Throughput
+		period.AvgFlowEfficiency /= float64(period.Throughput)
+	}
+	period.CycleTimeP50 = metrics.Percentile(cycleTimes, 50)
+	period.CycleTimeP85 = metrics.Percentile(cycleTimes, 85)
+
+	timesToMerge := []int{}
+	for _, mr := range result.MergeRequests {
+		// The merge dates are in the DisplayDateFormat of the client, their first 10 characters are the date
+		if len(mr.MergedAt) < 10 || mr.TimeToMerge < 0 {
+			continue
+		}
+		if mergedAt := mr.MergedAt[:10]; mergedAt >= period.StartDate && mergedAt <= period.EndDate {
+			timesToMerge = append(timesToMerge, mr.TimeToMerge)
+		}
+	}
+	period.MergeRequests = len(timesToMerge)
+	period.TimeToMergeP50 = metrics.Percentile(timesToMerge, 50)
+	period.TimeToMergeP85 = metrics.Percentile(timesToMerge, 85)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+)
+
+func TestSplitPeriods(t *testing.T) {
+	periods, err := splitPeriods("2024-01-01", "2024-01-17", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][2]string{{"2024-01-01", "2024-01-07"}, {"2024-01-08", "2024-01-14"}, {"2024-01-15", "2024-01-17"}}
+	if len(periods) != len(expected) {
+		t.Fatalf("Cantidad de períodos incorrecta, se esperaba %d pero se obtuvo %d", len(expected), len(periods))
+	}
+	for i, period := range periods {
+		if period.StartDate != expected[i][0] || period.EndDate != expected[i][1] {
+			t.Errorf("Período %d incorrecto, se esperaba %v pero se obtuvo %s - %s", i, expected[i], period.StartDate, period.EndDate)
+		}
+	}
+
+	if _, err := splitPeriods("2024-01-17", "2024-01-01", 7); err == nil {
+		t.Errorf("Se esperaba un error para un rango invertido")
+	}
+	if _, err := splitPeriods("2020-01-01", "2024-01-01", 7); err == nil {
+		t.Errorf("Se esperaba un error para más de %d períodos", maxPeriods)
+	}
+}
+
+func TestGetTrendData(t *testing.T) {
+	result := &DashboardData{
+		TaskMetrics: []TaskMetricsResponse{
+			{Id: "1", LeadTime: 6, CycleTime: 4, DoneAt: "2024-01-03"},
+			{Id: "2", LeadTime: 4, CycleTime: 2, DoneAt: "2024-01-05"},
+			{Id: "3", LeadTime: 10, CycleTime: 8, DoneAt: "2024-01-16"},
+			{Id: "4", LeadTime: 3, CycleTime: 3},
+		},
+		MergeRequests: []mergerequests.MergeRequest{
+			{MergedAt: "2024-01-02 10:00", TimeToMerge: 1},
+			{MergedAt: "2024-01-16 10:00", TimeToMerge: 3},
+		},
+	}
+	team := teams.Team{ID: "core", SprintDays: 14}
+	params := DashboardParams{StartDate: "2024-01-01", EndDate: "2024-01-28", Period: PeriodSprint, team: &team}
+	if err := getTrendData(result, params); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Trends) != 2 {
+		t.Fatalf("Cantidad de períodos incorrecta, se esperaba %d pero se obtuvo %d", 2, len(result.Trends))
+	}
+	first, last := result.Trends[0], result.Trends[1]
+	if first.Throughput != 2 || first.AvgCycleTime != 3 || first.CycleTimeP85 != 4 || first.MergeRequests != 1 {
+		t.Errorf("Primer período incorrecto, se obtuvo %+v", first)
+	}
+	if last.Throughput != 1 || last.TimeToMergeP50 != 3 {
+		t.Errorf("Último período incorrecto, se obtuvo %+v", last)
+	}
+	if result.Deltas == nil || result.Deltas.CycleTime != 5 || result.Deltas.LeadTime != 5 {
+		t.Errorf("Diferencias incorrectas, se obtuvo %+v", result.Deltas)
+	}
+	if len(result.TrendThroughputData.Data) != 2 || result.TrendThroughputData.Labels[1] != "2024-01-15" {
+		t.Errorf("Gráfico de throughput incorrecto, se obtuvo %+v", result.TrendThroughputData)
+	}
+}
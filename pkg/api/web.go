@@ -0,0 +1,199 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/lucasvillalbaar/clickup-metrics/web"
+)
+
+// assetHashLength is the number of hex characters of the content hash added to the static file names
+const assetHashLength = 10
+
+var (
+	// devMode reloads the templates and static files from disk on every request, it is set with DEV_MODE=true
+	devMode bool
+	// webFiles has the templates and static folders
+	webFiles = web.Files(false)
+	// dashboardTemplates are parsed once at startup, unless devMode is set
+	dashboardTemplates *template.Template
+	// assetHashes has the content hash of every static file, by its path inside the static folder
+	assetHashes map[string]string
+
+	hashedAssetPattern = regexp.MustCompile(`^(.+)\.([0-9a-f]{10})(\.[^./]+)$`)
+)
+
+// configureWeb parses the templates of the dashboard, or prepares to read them from disk in dev mode
+func configureWeb() {
+	devMode = os.Getenv("DEV_MODE") == "true"
+	webFiles = web.Files(devMode)
+	if devMode {
+		log.Println("Dev mode: reading templates and static files from", web.DevDir)
+		return
+	}
+
+	hashes, err := hashAssets(staticFiles())
+	if err != nil {
+		log.Fatal("Error reading static files: ", err)
+	}
+	assetHashes = hashes
+
+	// The dashboard breaks without its libraries, and they can't be downloaded at runtime
+	if err := web.CheckVendored(staticFiles()); err != nil {
+		log.Fatal("Error checking the vendored static files: ", err)
+	}
+
+	tmpl, err := parseDashboardTemplates()
+	if err != nil {
+		log.Fatal("Error when reading template: ", err)
+	}
+	dashboardTemplates = tmpl
+}
+
+// getDashboardTemplates returns the templates parsed at startup, or parses them again in dev mode,
+// with their texts in lang
+func getDashboardTemplates(lang string) (*template.Template, error) {
+	tmpl := dashboardTemplates
+	if tmpl == nil || devMode {
+		parsed, err := parseDashboardTemplates()
+		if err != nil {
+			return nil, err
+		}
+		tmpl = parsed
+	}
+
+	// The parsed templates are shared by the requests, the language is set on a copy
+	localized, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return localized.Funcs(template.FuncMap{"t": i18n.Translator(lang)}), nil
+}
+
+// getLanguage returns the language of the request: the lang query param if it is supported,
+// or the one preferred by the Accept-Language header
+func getLanguage(r *http.Request) string {
+	if lang := r.URL.Query().Get("lang"); i18n.Supported(lang) {
+		return lang
+	}
+	return i18n.Match(r.Header.Get("Accept-Language"))
+}
+
+// parseDashboardTemplates parses the templates of the dashboard
+func parseDashboardTemplates() (*template.Template, error) {
+	// Register the toJson function as a custom template function
+	funcMap := template.FuncMap{
+		"toJson":  toJson,
+		"minutes": secondsToMinutes,
+		"asset":   assetURL,
+		"t":       i18n.Translator(i18n.Default),
+	}
+
+	return template.New("dashboard.gohtml").Funcs(funcMap).ParseFS(webFiles, "templates/*.gohtml")
+}
+
+// staticFiles returns the static folder
+func staticFiles() fs.FS {
+	static, err := fs.Sub(webFiles, "static")
+	if err != nil {
+		log.Fatal("Error reading static files: ", err)
+	}
+	return static
+}
+
+// hashAssets returns the content hash of every file of the static folder
+func hashAssets(static fs.FS) (map[string]string, error) {
+	hashes := map[string]string{}
+	err := fs.WalkDir(static, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(static, name)
+		if err != nil {
+			return err
+		}
+		hashes[name] = contentHash(content)
+		return nil
+	})
+	return hashes, err
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:assetHashLength]
+}
+
+// assetURL returns the URL of a static file with its content hash in the name, e.g. /static/styles.0123456789.css,
+// so browsers can cache it until it changes. In dev mode the files change without restarting, so the plain URL is used.
+func assetURL(name string) string {
+	hash, ok := assetHashes[name]
+	if devMode || !ok {
+		return "/static/" + name
+	}
+	ext := path.Ext(name)
+	return "/static/" + strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// resolveAsset returns the path inside the static folder of a name with or without content hash,
+// and whether the hash matches the content of the file
+func resolveAsset(name string) (string, bool) {
+	match := hashedAssetPattern.FindStringSubmatch(name)
+	if match == nil {
+		return name, false
+	}
+	original := match[1] + match[3]
+	if _, err := fs.Stat(staticFiles(), original); err != nil {
+		// Not a hash, just a name with dots
+		return name, false
+	}
+	return original, currentHash(original) == match[2]
+}
+
+// currentHash returns the content hash of a static file, computed again in dev mode because the file may have changed
+func currentHash(name string) string {
+	if !devMode && assetHashes != nil {
+		return assetHashes[name]
+	}
+	content, err := fs.ReadFile(staticFiles(), name)
+	if err != nil {
+		return ""
+	}
+	return contentHash(content)
+}
+
+// getStaticHandler serves the static files. The URLs with the content hash are cached by browsers for a year,
+// the plain ones are revalidated with their ETag on every request.
+func getStaticHandler(w http.ResponseWriter, r *http.Request) {
+	requested := strings.TrimPrefix(r.URL.Path, "/static/")
+	name, hashed := resolveAsset(requested)
+	if name != requested && !hashed {
+		// An old version of the file, serving the new one would be cached as the old one
+		http.NotFound(w, r)
+		return
+	}
+
+	content, err := fs.ReadFile(staticFiles(), name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if hashed {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"`+contentHash(content)+`"`)
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
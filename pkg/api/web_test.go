@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/i18n"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/teams"
+	"github.com/lucasvillalbaar/clickup-metrics/web"
+)
+
+func TestDashboardTemplates(t *testing.T) {
+	data := &DashboardData{StartDate: "2024-01-01", EndDate: "2024-01-31", TaskMetrics: []TaskMetricsResponse{{Id: "1", CustomId: "PRJ-1"}},
+		Team: "core", Teams: []teams.Team{{ID: "core", Name: "Core", Projects: []int{123}}},
+		Period: PeriodWeek, Trends: []PeriodMetrics{{StartDate: "2024-01-01", EndDate: "2024-01-07"}}, Deltas: &TrendDeltas{CycleTime: -2},
+		CycleTimeHistogram: newDaysHistogram("cycle-time-histogram-chart", "Cycle Time - Histogram", []int{3}, defaultDayBuckets),
+		SLE:                &SLEReport{Percentile: 85, Days: 10, AtRisk: []AgingTask{{Id: "2", CustomId: "PRJ-2", Age: 12, DaysLeft: -2, Breached: true}}},
+		CycleTimeScatter:   newCycleTimeScatter([]TaskMetricsResponse{{Id: "1", CustomId: "PRJ-1", CycleTime: 3, DoneAt: "2024-01-05"}})}
+	titles := map[string]string{i18n.Spanish: "Métricas Kanban", i18n.English: "Kanban metrics"}
+	for lang, title := range titles {
+		tmpl, err := getDashboardTemplates(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "PRJ-1") {
+			t.Errorf("El dashboard no incluye el ticket")
+		}
+		if !strings.Contains(buf.String(), `data-projects="123" selected>Core</option>`) {
+			t.Errorf("El dashboard no tiene el equipo seleccionado")
+		}
+		if !strings.Contains(buf.String(), `id="trendsContent"`) || !strings.Contains(buf.String(), "-2 ") {
+			t.Errorf("El dashboard no tiene las tendencias")
+		}
+		if !strings.Contains(buf.String(), `id="sleContent"`) || !strings.Contains(buf.String(), "PRJ-2") {
+			t.Errorf("El dashboard no tiene el SLE")
+		}
+		if !strings.Contains(buf.String(), `id="cycle-time-histogram-chart"`) {
+			t.Errorf("El dashboard no tiene el histograma del cycle time")
+		}
+		if !strings.Contains(buf.String(), `id="cycle-time-scatter-chart"`) {
+			t.Errorf("El dashboard no tiene el scatterplot del cycle time")
+		}
+		if !strings.Contains(buf.String(), "<h1>"+title+"</h1>") {
+			t.Errorf("El dashboard en %s no tiene el título %s", lang, title)
+		}
+	}
+
+	if _, err := fs.ReadFile(staticFiles(), "styles.css"); err != nil {
+		t.Errorf("No se encontró styles.css: %v", err)
+	}
+}
+
+func TestVendoredAssets(t *testing.T) {
+	if err := web.CheckVendored(staticFiles()); err != nil {
+		t.Errorf("Librerías del dashboard incorrectas: %v", err)
+	}
+
+	asset := web.Asset{Path: "lib/test.js", Integrity: web.Hash([]byte("test"))}
+	if err := asset.Verify([]byte("changed")); err == nil {
+		t.Errorf("Se esperaba un error para un archivo modificado")
+	}
+	asset.Integrity = ""
+	if err := asset.Verify([]byte("test")); err == nil {
+		t.Errorf("Se esperaba un error para un archivo sin hash")
+	}
+}
+
+func TestStaticHandler(t *testing.T) {
+	hashes, err := hashAssets(staticFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetHashes = hashes
+	defer func() { assetHashes = nil }()
+
+	url := assetURL("styles.css")
+	if url != "/static/styles."+hashes["styles.css"]+".css" {
+		t.Fatalf("URL incorrecta: %s", url)
+	}
+
+	tests := []struct {
+		url          string
+		status       int
+		cacheControl string
+	}{
+		{url, http.StatusOK, "public, max-age=31536000, immutable"},
+		{"/static/styles.css", http.StatusOK, "no-cache"},
+		{"/static/styles.0123456789.css", http.StatusNotFound, ""},
+		{"/static/lib/bootstrap-5.3.2/bootstrap.min.css", http.StatusOK, "no-cache"},
+		{"/static/missing.css", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		getStaticHandler(recorder, httptest.NewRequest("GET", test.url, nil))
+		if recorder.Code != test.status {
+			t.Errorf("Status de %s incorrecto, se esperaba %d pero se obtuvo %d", test.url, test.status, recorder.Code)
+		}
+		if got := recorder.Header().Get("Cache-Control"); got != test.cacheControl {
+			t.Errorf("Cache-Control de %s incorrecto, se esperaba %q pero se obtuvo %q", test.url, test.cacheControl, got)
+		}
+	}
+
+	content, err := getAsset(url)
+	if err != nil || !strings.Contains(string(content), "body") {
+		t.Errorf("No se pudo leer %s para el reporte: %v", url, err)
+	}
+}
+
+func TestGetLanguage(t *testing.T) {
+	cases := []struct {
+		url            string
+		acceptLanguage string
+		expected       string
+	}{
+		{"/dashboard", "", i18n.Spanish},
+		{"/dashboard", "en-US,en;q=0.9", i18n.English},
+		{"/dashboard?lang=es", "en-US,en;q=0.9", i18n.Spanish},
+		{"/dashboard?lang=fr", "en", i18n.English},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", c.url, nil)
+		r.Header.Set("Accept-Language", c.acceptLanguage)
+		if result := getLanguage(r); result != c.expected {
+			t.Errorf("Idioma de %s (%s) incorrecto, se esperaba %s pero se obtuvo %s", c.url, c.acceptLanguage, c.expected, result)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Package cache keeps copies of the responses of the ClickUp and GitLab APIs, so refreshing the dashboard doesn't
+fetch every task and every merge request again.
+
+Values are stored as JSON, so every Store implementation behaves the same regardless of where it keeps them.
+Entries expire after the TTL given when they are set: a short one for data that can still change (open tasks,
+lists of merge requests) and a long one for data that can't (done tasks, merged merge requests).
+*/
+package cache
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Store keeps values for a while
+type Store interface {
+	// Get decodes the value of the key into v, and reports whether it was found and not expired
+	Get(key string, v interface{}) bool
+	// Set keeps the value of the key until the ttl expires
+	Set(key string, v interface{}, ttl time.Duration)
+	// Delete removes the value of the key, if any
+	Delete(key string)
+}
+
+// TTL are the times entries are kept, depending on whether the data can still change
+type TTL struct {
+	Short time.Duration // Open tasks and anything that can still change
+	Long  time.Duration // Done tasks and merged merge requests
+}
+
+// DefaultTTL keeps changing data for 10 minutes and finished data for a week
+var DefaultTTL = TTL{
+	Short: 10 * time.Minute,
+	Long:  7 * 24 * time.Hour,
+}
+
+// entry is the stored representation of a value
+type entry struct {
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+func (e *entry) expired() bool {
+	return time.Now().After(e.ExpiresAt)
+}
+
+// newEntry encodes the value, returning false if it can't be stored
+func newEntry(v interface{}, ttl time.Duration) (entry, bool) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		log.Println("Cache: error encoding value:", err)
+		return entry{}, false
+	}
+
+	return entry{
+		Value:     value,
+		ExpiresAt: time.Now().Add(ttl),
+	}, true
+}
+
+// decode decodes the entry into v, returning false if it doesn't match v
+func (e *entry) decode(v interface{}) bool {
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		log.Println("Cache: error decoding value:", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+)
+
+func testStore(t *testing.T, store Store) {
+	type value struct {
+		Name string
+	}
+
+	store.Set("task:1", value{Name: "first"}, time.Minute)
+	store.Set("task:2", value{Name: "expired"}, -time.Minute)
+
+	var v value
+	if !store.Get("task:1", &v) || v.Name != "first" {
+		t.Errorf("Se esperaba encontrar task:1 pero se obtuvo %+v", v)
+	}
+	if store.Get("task:2", &v) {
+		t.Errorf("No se esperaba encontrar task:2, está vencido")
+	}
+	if store.Get("task:3", &v) {
+		t.Errorf("No se esperaba encontrar task:3")
+	}
+
+	store.Delete("task:1")
+	if store.Get("task:1", &v) {
+		t.Errorf("No se esperaba encontrar task:1 luego de borrarlo")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestDisk(t *testing.T) {
+	store, err := NewDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
+
+type fakeSource struct {
+	calls  int
+	status string
+}
+
+func (f *fakeSource) GetTasksWithFilter(filter data.Filter) ([]data.TaskHeaderData, error) {
+	return nil, nil
+}
+func (f *fakeSource) GetHistoryPerTask(ids []string) *map[string]data.TaskInfo { return nil }
+func (f *fakeSource) GetWorkflow() *data.Workflow {
+	return &data.Workflow{Statuses: []data.Status{{Name: "in development"}, {Name: "completed", Done: true}}}
+}
+func (f *fakeSource) GetTaskByID(id string) (*data.TaskInfo, error) {
+	f.calls++
+	return &data.TaskInfo{TaskHeaderData: data.TaskHeaderData{Id: id, Status: f.status}}, nil
+}
+
+func TestDataSource(t *testing.T) {
+	source := &fakeSource{status: "completed"}
+	// Open tasks are never kept, done tasks are kept for an hour
+	cached := NewDataSource(source, NewMemory(), TTL{Short: -time.Minute, Long: time.Hour})
+
+	cached.GetTaskByID("85zt8cyjd")
+	task, _ := cached.GetTaskByID("85zt8cyjd")
+	if source.calls != 1 || task.Id != "85zt8cyjd" {
+		t.Errorf("Se esperaba 1 llamada para una tarea terminada pero se obtuvieron %d", source.calls)
+	}
+
+	cached.Refresh("85zt8cyjd")
+	cached.GetTaskByID("85zt8cyjd")
+	if source.calls != 2 {
+		t.Errorf("Se esperaban 2 llamadas luego de refrescar pero se obtuvieron %d", source.calls)
+	}
+
+	source.status = "in development"
+	cached.GetTaskByID("85aaaaaaa")
+	cached.GetTaskByID("85aaaaaaa")
+	if source.calls != 4 {
+		t.Errorf("Se esperaban 4 llamadas para una tarea abierta pero se obtuvieron %d", source.calls)
+	}
+}
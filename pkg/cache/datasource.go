@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"strings"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+)
+
+const taskKeyPrefix = "task:"
+
+// DataSource is a data.Data that keeps the tasks retrieved from another data source in a Store.
+// Done tasks are kept for the long TTL, as they are not expected to change anymore.
+type DataSource struct {
+	source     data.Data
+	store      Store
+	ttl        TTL
+	doneStatus map[string]bool
+}
+
+// NewDataSource wraps the data source with the store
+func NewDataSource(source data.Data, store Store, ttl TTL) *DataSource {
+	doneStatus := make(map[string]bool)
+	if wf := source.GetWorkflow(); wf != nil {
+		for _, status := range wf.Statuses {
+			doneStatus[strings.ToLower(status.Name)] = status.Done
+		}
+	}
+
+	return &DataSource{
+		source:     source,
+		store:      store,
+		ttl:        ttl,
+		doneStatus: doneStatus,
+	}
+}
+
+func (d *DataSource) GetTasksWithFilter(filter data.Filter) ([]data.TaskHeaderData, error) {
+	return d.source.GetTasksWithFilter(filter)
+}
+
+func (d *DataSource) GetTaskByID(id string) (*data.TaskInfo, error) {
+	var task data.TaskInfo
+	if d.store.Get(taskKeyPrefix+id, &task) {
+		return &task, nil
+	}
+
+	result, err := d.source.GetTaskByID(id)
+	if err != nil {
+		return result, err
+	}
+
+	ttl := d.ttl.Short
+	if d.doneStatus[strings.ToLower(result.Status)] {
+		ttl = d.ttl.Long
+	}
+	d.store.Set(taskKeyPrefix+id, result, ttl)
+
+	return result, nil
+}
+
+func (d *DataSource) GetHistoryPerTask(ids []string) *map[string]data.TaskInfo {
+	return d.source.GetHistoryPerTask(ids)
+}
+
+func (d *DataSource) GetWorkflow() *data.Workflow {
+	return d.source.GetWorkflow()
+}
+
+// Refresh forgets the task, so the next time it is requested it is retrieved from the wrapped data source
+func (d *DataSource) Refresh(id string) {
+	d.store.Delete(taskKeyPrefix + id)
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Disk is a Store that keeps every value in a file of a directory, so they survive restarts
+type Disk struct {
+	dir string
+}
+
+// NewDisk creates a store in the directory, creating it if it doesn't exist
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Disk{dir: dir}, nil
+}
+
+// path returns the file of the key. Keys are URLs and IDs, so they are hashed to get a valid file name.
+func (d *Disk) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+func (d *Disk) Get(key string, v interface{}) bool {
+	content, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return false
+	}
+
+	var e entry
+	if err := json.Unmarshal(content, &e); err != nil || e.expired() {
+		d.Delete(key)
+		return false
+	}
+
+	return e.decode(v)
+}
+
+func (d *Disk) Set(key string, v interface{}, ttl time.Duration) {
+	e, ok := newEntry(v, ttl)
+	if !ok {
+		return
+	}
+
+	content, err := json.Marshal(e)
+	if err != nil {
+		log.Println("Cache: error encoding entry:", err)
+		return
+	}
+
+	// Write to a temporary file first, so concurrent readers never see half a file
+	tmp, err := os.CreateTemp(d.dir, "*.tmp")
+	if err != nil {
+		log.Println("Cache: error creating file:", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		log.Println("Cache: error writing file:", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		log.Println("Cache: error writing file:", err)
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		log.Println("Cache: error writing file:", err)
+	}
+}
+
+func (d *Disk) Delete(key string) {
+	if err := os.Remove(d.path(key)); err != nil && !os.IsNotExist(err) {
+		log.Println("Cache: error deleting file:", err)
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory is a Store that keeps the values in memory, they are lost when the service restarts
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{
+		entries: make(map[string]entry),
+	}
+}
+
+func (m *Memory) Get(key string, v interface{}) bool {
+	m.mu.Lock()
+	e, ok := m.entries[key]
+	if ok && e.expired() {
+		delete(m.entries, key)
+		ok = false
+	}
+	m.mu.Unlock()
+
+	return ok && e.decode(v)
+}
+
+func (m *Memory) Set(key string, v interface{}, ttl time.Duration) {
+	e, ok := newEntry(v, ttl)
+	if !ok {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = e
+}
+
+func (m *Memory) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+}
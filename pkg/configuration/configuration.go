@@ -31,6 +31,7 @@ import (
 
 type EnvVars struct {
 	ApiKey string
+	TeamID string // Optional, required to look tasks up by their custom ID
 }
 
 var envVars EnvVars
@@ -45,6 +46,11 @@ func getEnvVariable(key string) string {
 	return value
 }
 
+// getOptionalEnvVariable retrieves the value of the specified environment variable, or an empty string if it is not set.
+func getOptionalEnvVariable(key string) string {
+	return os.Getenv(key)
+}
+
 // GetEnvironmentVariables returns the loaded environment variables.
 func GetEnvironmentVariables() EnvVars {
 	return envVars
@@ -55,6 +61,7 @@ func GetEnvironmentVariables() EnvVars {
 func LoadEnvironmentVariables() error {
 	envVars = EnvVars{
 		ApiKey: getEnvVariable("API_KEY"),
+		TeamID: getOptionalEnvVariable("CLICKUP_TEAM_ID"),
 	}
 	if envVars.ApiKey == "" {
 		return fmt.Errorf("API_KEY not found")
@@ -2,11 +2,11 @@ package clickup
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"regexp"
 
 	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
 )
@@ -44,19 +44,43 @@ const (
 const (
 	EndpointTaskHistory = "https://api.clickup.com/api/v2/task/%s/time_in_status"
 	EndpointTaskInfo    = "https://api.clickup.com/api/v2/task/%s"
+	WebTaskURL          = "https://app.clickup.com/t/%s" // Page of the task in the ClickUp app
 )
 
+type ResponseTaskStatus struct {
+	Status string `json:"status"`
+	Type   string `json:"type"` // open, custom, done or closed
+}
+
+type ResponseTaskList struct {
+	Id string `json:"id"`
+}
+
 type ResponseGetTask struct {
-	Id        string `json:"id"`
-	CustomId  string `json:"custom_id"`
-	Name      string `json:"name"`
-	StartDate string `json:"start_date"`
-	DueDate   string `json:"due_date"`
+	Id        string             `json:"id"`
+	CustomId  string             `json:"custom_id"`
+	Name      string             `json:"name"`
+	StartDate string             `json:"start_date"`
+	DueDate   string             `json:"due_date"`
+	Status    ResponseTaskStatus `json:"status"`
+	List      ResponseTaskList   `json:"list"`
+}
+
+// customIDPattern matches the custom task IDs ClickUp generates from a prefix, like CORE-123
+var customIDPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*-[0-9]+$`)
+
+// taskURL builds the URL of a task endpoint, asking ClickUp to resolve the ID as a custom ID when it looks like one
+func (s *Session) taskURL(endpoint string, taskId string) string {
+	url := fmt.Sprintf(endpoint, taskId)
+	if s.teamID != "" && customIDPattern.MatchString(taskId) {
+		url = url + "?custom_task_ids=true&team_id=" + s.teamID
+	}
+	return url
 }
 
 // getTaskHistory retrieves the task history from the ClickUp API
-func getTaskHistory(taskId string) ([]data.History, error) {
-	url := fmt.Sprintf(EndpointTaskHistory, taskId)
+func (s *Session) getTaskHistory(taskId string) ([]data.History, error) {
+	url := s.taskURL(EndpointTaskHistory, taskId)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -69,25 +93,25 @@ func getTaskHistory(taskId string) ([]data.History, error) {
 	resp, err := client.Do(req)
 
 	if err != nil {
-		return nil, fmt.Errorf("error performing HTTP request: %v", err)
+		return nil, fmt.Errorf("%w: error performing HTTP request: %v", data.ErrUpstream, err)
 	}
 
-	if resp.StatusCode == 401 {
-		return nil, errors.New("token is expired")
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, taskId); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
-
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
+		return nil, fmt.Errorf("%w: error reading response body: %v", data.ErrUpstream, err)
 	}
 
 	timeInStatus := data.TimeInStatusResponse{}
 
 	err = json.Unmarshal(body, &timeInStatus)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: error parsing body: %v", data.ErrUpstream, err)
 	}
 
 	history := []data.History{}
@@ -103,9 +127,25 @@ func getTaskHistory(taskId string) ([]data.History, error) {
 	return history, nil
 }
 
+// checkResponse converts the error status codes of the ClickUp API into the errors of the data package
+func checkResponse(resp *http.Response, taskId string) error {
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized:
+		return data.ErrUnauthorized
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", data.ErrNotFound, taskId)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return data.ErrRateLimited
+	default:
+		return fmt.Errorf("%w: ClickUp responded %d for task %s", data.ErrUpstream, resp.StatusCode, taskId)
+	}
+}
+
 // getTaskHeaderData retrieves the task header data from the ClickUp API
-func getTaskHeaderData(taskId string) (data.TaskHeaderData, error) {
-	url := fmt.Sprintf(EndpointTaskInfo, taskId)
+func (s *Session) getTaskHeaderData(taskId string) (data.TaskHeaderData, error) {
+	url := s.taskURL(EndpointTaskInfo, taskId)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -118,24 +158,25 @@ func getTaskHeaderData(taskId string) (data.TaskHeaderData, error) {
 	resp, err := client.Do(req)
 	log.Println("Fetching data from Clickup for ticket:", taskId)
 	if err != nil {
-		return data.TaskHeaderData{}, fmt.Errorf("error performing HTTP request: %v", err)
+		return data.TaskHeaderData{}, fmt.Errorf("%w: error performing HTTP request: %v", data.ErrUpstream, err)
 	}
 
-	if resp.StatusCode == 401 {
-		return data.TaskHeaderData{}, errors.New("api key is expired or is not valid")
-	}
 	defer resp.Body.Close()
 
+	if err := checkResponse(resp, taskId); err != nil {
+		return data.TaskHeaderData{}, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return data.TaskHeaderData{}, fmt.Errorf("error reading response body: %v", err)
+		return data.TaskHeaderData{}, fmt.Errorf("%w: error reading response body: %v", data.ErrUpstream, err)
 	}
 
 	var response ResponseGetTask
 
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return data.TaskHeaderData{}, fmt.Errorf("error parsing body: %v", err)
+		return data.TaskHeaderData{}, fmt.Errorf("%w: error parsing body: %v", data.ErrUpstream, err)
 	}
 
 	return data.TaskHeaderData{
@@ -144,16 +185,18 @@ func getTaskHeaderData(taskId string) (data.TaskHeaderData, error) {
 		Name:      response.Name,
 		StartDate: response.StartDate,
 		DueDate:   response.DueDate,
+		Status:    response.Status.Status,
+		ListID:    response.List.Id,
 	}, nil
 }
 
 // getTaskInfo retrieves the task information including history and header data
-func getTaskInfo(taskId string) (*data.TaskInfo, error) {
-	history, err := getTaskHistory(taskId)
+func (s *Session) getTaskInfo(taskId string) (*data.TaskInfo, error) {
+	history, err := s.getTaskHistory(taskId)
 	if err != nil {
 		return &data.TaskInfo{}, err
 	}
-	taskHeaderData, err := getTaskHeaderData(taskId)
+	taskHeaderData, err := s.getTaskHeaderData(taskId)
 
 	if err != nil {
 		return &data.TaskInfo{}, err
@@ -167,24 +210,29 @@ func getTaskInfo(taskId string) (*data.TaskInfo, error) {
 
 type Session struct {
 	apiKey string
+	teamID string
 }
 
-var s *Session
-
-func Init(apiKey string) *Session {
-	s = &Session{
+// NewSession creates a ClickUp session. teamID (the workspace ID) is only needed to look tasks up by their custom ID.
+// A session can be used by concurrent requests.
+func NewSession(apiKey string, teamID string) *Session {
+	return &Session{
 		apiKey: apiKey,
+		teamID: teamID,
 	}
-
-	return s
 }
 
-func (s *Session) GetTasksWithFilter(filter data.Filter) []data.TaskHeaderData {
-	return nil
+// GetTasksWithFilter retrieves the tasks of filter.ListID, closed ones included.
+// If a page can't be retrieved, the error is returned along with the tasks retrieved until then.
+func (s *Session) GetTasksWithFilter(filter data.Filter) ([]data.TaskHeaderData, error) {
+	if filter.ListID == "" {
+		return nil, nil
+	}
+	return s.getListTasks(filter)
 }
 
 func (s *Session) GetTaskByID(id string) (*data.TaskInfo, error) {
-	return getTaskInfo(id)
+	return s.getTaskInfo(id)
 }
 
 func (s *Session) GetHistoryPerTask(ids []string) *map[string]data.TaskInfo {
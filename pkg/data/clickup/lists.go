@@ -0,0 +1,103 @@
+package clickup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+)
+
+const (
+	EndpointListTasks = "https://api.clickup.com/api/v2/list/%s/task"
+	TaskTypeClosed    = "closed"
+	TaskTypeDone      = "done"
+)
+
+type ResponseGetListTasks struct {
+	Tasks    []ResponseGetTask `json:"tasks"`
+	LastPage bool              `json:"last_page"`
+}
+
+// listTasksURL builds the URL of a page of the tasks of the list, with the dates of filter as ClickUp params
+func listTasksURL(filter data.Filter, page int) string {
+	params := url.Values{}
+	params.Set("page", strconv.Itoa(page))
+	params.Set("include_closed", "true")
+	params.Set("subtasks", "true")
+	if !filter.DueDateAfter.IsZero() {
+		params.Set("due_date_gt", strconv.FormatInt(filter.DueDateAfter.UnixMilli(), 10))
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		params.Set("date_updated_gt", strconv.FormatInt(filter.UpdatedAfter.UnixMilli(), 10))
+	}
+	return fmt.Sprintf(EndpointListTasks, filter.ListID) + "?" + params.Encode()
+}
+
+// getListTasks retrieves every page of the tasks of the list
+func (s *Session) getListTasks(filter data.Filter) ([]data.TaskHeaderData, error) {
+	tasks := []data.TaskHeaderData{}
+
+	for page := 0; ; page++ {
+		req, err := http.NewRequest("GET", listTasksURL(filter, page), nil)
+		if err != nil {
+			return tasks, fmt.Errorf("error creating HTTP request: %v", err)
+		}
+
+		req.Header.Set("Authorization", s.apiKey)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		log.Printf("Fetching page %d of list %s from Clickup", page, filter.ListID)
+		if err != nil {
+			return tasks, fmt.Errorf("%w: error performing HTTP request: %v", data.ErrUpstream, err)
+		}
+
+		response, err := readListTasks(resp, filter.ListID)
+		if err != nil {
+			return tasks, err
+		}
+
+		for _, task := range response.Tasks {
+			if filter.OnlyClosedTasks && task.Status.Type != TaskTypeClosed && task.Status.Type != TaskTypeDone {
+				continue
+			}
+			tasks = append(tasks, data.TaskHeaderData{
+				Id:        task.Id,
+				CustomId:  task.CustomId,
+				Name:      task.Name,
+				StartDate: task.StartDate,
+				DueDate:   task.DueDate,
+				Status:    task.Status.Status,
+				ListID:    filter.ListID,
+			})
+		}
+
+		if response.LastPage || len(response.Tasks) == 0 {
+			return tasks, nil
+		}
+	}
+}
+
+func readListTasks(resp *http.Response, listID string) (ResponseGetListTasks, error) {
+	defer resp.Body.Close()
+
+	var response ResponseGetListTasks
+	if err := checkResponse(resp, "list "+listID); err != nil {
+		return response, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response, fmt.Errorf("%w: error reading response body: %v", data.ErrUpstream, err)
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return response, fmt.Errorf("%w: error parsing body: %v", data.ErrUpstream, err)
+	}
+	return response, nil
+}
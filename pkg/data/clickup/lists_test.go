@@ -0,0 +1,34 @@
+package clickup
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+)
+
+func TestListTasksURL(t *testing.T) {
+	updatedAfter := time.UnixMilli(1700000000000)
+	result, err := url.Parse(listTasksURL(data.Filter{ListID: "901", UpdatedAfter: updatedAfter}, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Path != "/api/v2/list/901/task" {
+		t.Errorf("Ruta incorrecta, se esperaba %s pero se obtuvo %s", "/api/v2/list/901/task", result.Path)
+	}
+
+	expected := map[string]string{
+		"page":            "2",
+		"include_closed":  "true",
+		"subtasks":        "true",
+		"date_updated_gt": "1700000000000",
+		"due_date_gt":     "",
+	}
+	for param, value := range expected {
+		if result.Query().Get(param) != value {
+			t.Errorf("Parámetro %s incorrecto, se esperaba %q pero se obtuvo %q", param, value, result.Query().Get(param))
+		}
+	}
+}
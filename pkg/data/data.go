@@ -1,6 +1,17 @@
 package data
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// Errors returned by the data sources, wrapped with the details of the failed request
+var (
+	ErrUnauthorized = errors.New("api key is expired or is not valid")
+	ErrNotFound     = errors.New("task not found")
+	ErrRateLimited  = errors.New("rate limit exceeded")
+	ErrUpstream     = errors.New("data source error")
+)
 
 type History struct {
 	Status string
@@ -28,6 +39,8 @@ type TaskHeaderData struct {
 	CustomId  string
 	StartDate string
 	DueDate   string
+	Status    string
+	ListID    string // ClickUp list the task belongs to
 }
 
 type TaskInfo struct {
@@ -37,8 +50,10 @@ type TaskInfo struct {
 
 type Filter struct {
 	ProjectID       string
+	ListID          string // ClickUp list the tasks belong to
 	TaskType        []string
 	DueDateAfter    time.Time
+	UpdatedAfter    time.Time
 	OnlyClosedTasks bool
 }
 
@@ -58,26 +73,14 @@ type Workflow struct {
 }
 
 type Data interface {
-	GetTasksWithFilter(filter Filter) []TaskHeaderData
+	GetTasksWithFilter(filter Filter) ([]TaskHeaderData, error)
 	GetTaskByID(id string) (*TaskInfo, error)
 	GetHistoryPerTask(ids []string) *map[string]TaskInfo
 	GetWorkflow() *Workflow
 }
 
-var data Data
-
-func SetDataSource(d Data) {
-	data = d
-}
-
-func GetTasksWithFilter(filter Filter) []TaskHeaderData {
-	return data.GetTasksWithFilter(filter)
-}
-
-func GetTaskByID(id string) (*TaskInfo, error) {
-	return data.GetTaskByID(id)
-}
-
-func GetHistoryPerTask(ids []string) *map[string]TaskInfo {
-	return data.GetHistoryPerTask(ids)
+// Refresher is implemented by the data sources that keep copies of the tasks
+type Refresher interface {
+	// Refresh discards the copy of the task, if any
+	Refresh(id string)
 }
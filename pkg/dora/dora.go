@@ -0,0 +1,205 @@
+/*
+Package dora computes the DORA metrics (deployment frequency, lead time for changes, change failure rate
+and time to restore service) from the production deployments of a set of GitLab projects.
+*/
+package dora
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+)
+
+type Metrics struct {
+	Deployments               int     `json:"deployments"`
+	FailedDeployments         int     `json:"failed_deployments"`
+	DeploymentFrequency       float64 `json:"deployment_frequency"`        // Successful deployments per day
+	LeadTimeForChanges        float64 `json:"lead_time_for_changes"`       // Median hours from the first commit to the production deploy
+	ChangeFailureRate         float64 `json:"change_failure_rate"`         // Percentage of deployments that failed
+	TimeToRestore             float64 `json:"time_to_restore"`             // Mean hours from a failed deployment to the next successful one
+	ChangesDeployed           int     `json:"changes_deployed"`            // Merge requests shipped by the successful deployments
+	DeploymentsWithoutChanges int     `json:"deployments_without_changes"` // Successful deployments with no merge request associated
+	DeploymentsWithErrors     int     `json:"deployments_with_errors"`     // Successful deployments whose changes couldn't be retrieved
+}
+
+// maxConcurrentDeployments is the number of deployments whose changes are retrieved from GitLab at the same time
+const maxConcurrentDeployments = 5
+
+// Deployment is a production deployment with the lead times of the changes it shipped
+type Deployment struct {
+	ProjectID  int             `json:"project_id"`
+	ID         int             `json:"id"`
+	Ref        string          `json:"ref"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Failed     bool            `json:"failed"`
+	LeadTimes  []time.Duration `json:"lead_times"`
+	Error      string          `json:"error,omitempty"` // Why the lead times are missing or incomplete
+}
+
+// MarshalJSON writes the lead times in hours, like the rest of the DORA figures
+func (d Deployment) MarshalJSON() ([]byte, error) {
+	type deployment Deployment
+	hours := make([]float64, len(d.LeadTimes))
+	for i, leadTime := range d.LeadTimes {
+		hours[i] = leadTime.Hours()
+	}
+
+	return json.Marshal(struct {
+		deployment
+		LeadTimes []float64 `json:"lead_times"`
+	}{deployment(d), hours})
+}
+
+// Collect retrieves the deployments of the projects to the environment between startDate and endDate (YYYY-MM-DD),
+// along with the lead time of every merge request they shipped. The changes of at most maxConcurrentDeployments
+// deployments are retrieved at the same time.
+func Collect(c *mergerequests.GitlabClient, projectIDs []int, environment string, startDate string, endDate string) ([]Deployment, error) {
+	// Every project is listed before the lead times are retrieved, so no goroutine is left running on an error
+	var deployments []mergerequests.Deployment
+	for _, projectID := range projectIDs {
+		projectDeployments, err := c.GetDeploymentsBetween(projectID, environment, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, projectDeployments...)
+	}
+
+	var result []Deployment
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrentDeployments)
+
+	for _, d := range deployments {
+		wg.Add(1)
+		go func(d mergerequests.Deployment) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			deployment := Deployment{
+				ProjectID:  d.ProjectID,
+				ID:         d.ID,
+				Ref:        d.Ref,
+				FinishedAt: d.FinishedAt(),
+				Failed:     d.Status == mergerequests.DeploymentStatusFailed,
+			}
+
+			if !deployment.Failed {
+				leadTimes, err := collectLeadTimes(c, d.ProjectID, d.ID, deployment.FinishedAt)
+				deployment.LeadTimes = leadTimes
+				if err != nil {
+					deployment.Error = err.Error()
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			result = append(result, deployment)
+		}(d)
+	}
+	wg.Wait()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FinishedAt.Before(result[j].FinishedAt)
+	})
+
+	return result, nil
+}
+
+// collectLeadTimes returns the time from the first commit of every merge request shipped by the deployment until it finished.
+// The lead times of the merge requests that could be retrieved are returned along with the error.
+func collectLeadTimes(c *mergerequests.GitlabClient, projectID int, deploymentID int, finishedAt time.Time) ([]time.Duration, error) {
+	mrs, err := c.GetDeploymentMergeRequests(projectID, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	leadTimes := []time.Duration{}
+	failed := 0
+	var lastErr error
+	for _, mr := range mrs {
+		firstCommit, err := c.GetFirstCommitDate(projectID, mr.IID)
+		if err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		leadTimes = append(leadTimes, finishedAt.Sub(firstCommit))
+	}
+
+	if lastErr != nil {
+		return leadTimes, fmt.Errorf("%d of %d merge requests without lead time: %w", failed, len(mrs), lastErr)
+	}
+	return leadTimes, nil
+}
+
+// Calculate computes the DORA metrics of the deployments, which must be sorted by FinishedAt, over a period of the given days
+func Calculate(deployments []Deployment, days int) Metrics {
+	metrics := Metrics{}
+	if days < 1 {
+		days = 1
+	}
+
+	leadTimes := []time.Duration{}
+	restoreTimes := []time.Duration{}
+	failedSince := map[int]time.Time{} // First unrestored failure per project
+
+	for _, d := range deployments {
+		if d.Failed {
+			metrics.FailedDeployments++
+			if _, ok := failedSince[d.ProjectID]; !ok {
+				failedSince[d.ProjectID] = d.FinishedAt
+			}
+			continue
+		}
+
+		metrics.Deployments++
+		switch {
+		case d.Error != "":
+			metrics.DeploymentsWithErrors++
+		case len(d.LeadTimes) == 0:
+			metrics.DeploymentsWithoutChanges++
+		}
+		metrics.ChangesDeployed += len(d.LeadTimes)
+		leadTimes = append(leadTimes, d.LeadTimes...)
+
+		if since, ok := failedSince[d.ProjectID]; ok {
+			restoreTimes = append(restoreTimes, d.FinishedAt.Sub(since))
+			delete(failedSince, d.ProjectID)
+		}
+	}
+
+	metrics.DeploymentFrequency = float64(metrics.Deployments) / float64(days)
+
+	if total := metrics.Deployments + metrics.FailedDeployments; total > 0 {
+		metrics.ChangeFailureRate = float64(metrics.FailedDeployments) * 100 / float64(total)
+	}
+
+	if len(leadTimes) > 0 {
+		sort.Slice(leadTimes, func(i, j int) bool { return leadTimes[i] < leadTimes[j] })
+		metrics.LeadTimeForChanges = median(leadTimes).Hours()
+	}
+
+	if len(restoreTimes) > 0 {
+		var total time.Duration
+		for _, restoreTime := range restoreTimes {
+			total += restoreTime
+		}
+		metrics.TimeToRestore = (total / time.Duration(len(restoreTimes))).Hours()
+	}
+
+	return metrics
+}
+
+// median returns the median of a sorted slice of durations
+func median(sorted []time.Duration) time.Duration {
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,60 @@
+package dora
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCalculate(t *testing.T) {
+	day := func(d int, h int) time.Time {
+		return time.Date(2023, 7, d, h, 0, 0, 0, time.UTC)
+	}
+
+	deployments := []Deployment{
+		{ProjectID: 1, FinishedAt: day(3, 10), LeadTimes: []time.Duration{10 * time.Hour, 30 * time.Hour}},
+		{ProjectID: 1, FinishedAt: day(4, 10), Failed: true},
+		{ProjectID: 2, FinishedAt: day(4, 11), LeadTimes: []time.Duration{20 * time.Hour}},
+		{ProjectID: 1, FinishedAt: day(4, 14)},
+	}
+
+	metrics := Calculate(deployments, 10)
+
+	if metrics.Deployments != 3 || metrics.FailedDeployments != 1 {
+		t.Errorf("Deployments incorrectos, se esperaba 3 y 1 fallido pero se obtuvo %d y %d", metrics.Deployments, metrics.FailedDeployments)
+	}
+	if metrics.DeploymentFrequency != 0.3 {
+		t.Errorf("Deployment Frequency incorrecto, se esperaba 0.3 pero se obtuvo %.2f", metrics.DeploymentFrequency)
+	}
+	if metrics.LeadTimeForChanges != 20 {
+		t.Errorf("Lead Time for Changes incorrecto, se esperaba 20 pero se obtuvo %.2f", metrics.LeadTimeForChanges)
+	}
+	if metrics.ChangeFailureRate != 25 {
+		t.Errorf("Change Failure Rate incorrecto, se esperaba 25 pero se obtuvo %.2f", metrics.ChangeFailureRate)
+	}
+	if metrics.TimeToRestore != 4 {
+		t.Errorf("Time to Restore incorrecto, se esperaba 4 pero se obtuvo %.2f", metrics.TimeToRestore)
+	}
+	if metrics.DeploymentsWithoutChanges != 1 {
+		t.Errorf("Deployments sin cambios incorrectos, se esperaba 1 pero se obtuvo %d", metrics.DeploymentsWithoutChanges)
+	}
+}
+
+func TestDeploymentJSON(t *testing.T) {
+	content, err := json.Marshal(Deployment{ID: 1, LeadTimes: []time.Duration{90 * time.Minute}, Error: "timeout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	leadTimes, ok := decoded["lead_times"].([]interface{})
+	if !ok || len(leadTimes) != 1 || leadTimes[0] != 1.5 {
+		t.Errorf("Lead times incorrectos, se esperaba [1.5] horas pero se obtuvo %s", content)
+	}
+	if decoded["error"] != "timeout" || decoded["id"] != 1.0 {
+		t.Errorf("Deployment incorrecto: %s", content)
+	}
+}
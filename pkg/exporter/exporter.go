@@ -0,0 +1,165 @@
+/*
+Package exporter publishes the flow metrics of the stored tasks and merge requests in the Prometheus format.
+
+The metrics are computed with the metrics package from the latest snapshot of every task each time
+Prometheus scrapes them, so they follow the collections of the scheduler without keeping any state.
+
+Usage:
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter.NewCollector(store, workflow))
+	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+*/
+package exporter
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ThroughputWindow is the time the throughput counts the tasks finished within
+const ThroughputWindow = 7 * 24 * time.Hour
+
+var (
+	// DayBuckets are the upper bounds, in days, of the cycle time and lead time histograms
+	DayBuckets = []float64{1, 2, 3, 5, 8, 13, 21, 34, 55, 89}
+	// TimeToMergeBuckets are the upper bounds, in business days, of the time to merge histogram
+	TimeToMergeBuckets = []float64{0, 1, 2, 3, 4, 5, 7, 10, 15}
+)
+
+// Source is where the collector reads the tasks and merge requests from, like the metrics store
+type Source interface {
+	GetLatestTasks() ([]data.TaskInfo, error)
+	GetLatestMergeRequests() ([]mergerequests.MergeRequest, error)
+}
+
+// Collector is a prometheus.Collector of the flow metrics per ClickUp list and the time to merge per GitLab project
+type Collector struct {
+	source Source
+	wf     metrics.Workflow
+	now    func() time.Time
+
+	cycleTime   *prometheus.Desc
+	leadTime    *prometheus.Desc
+	wip         *prometheus.Desc
+	throughput  *prometheus.Desc
+	timeToMerge *prometheus.Desc
+}
+
+// NewCollector creates the collector of the tasks and merge requests of source, classified with the statuses of wf
+func NewCollector(source Source, wf metrics.Workflow) *Collector {
+	return &Collector{
+		source: source,
+		wf:     wf,
+		now:    time.Now,
+
+		cycleTime: prometheus.NewDesc("flow_cycle_time_days",
+			"Cycle time of the finished tasks, in days", []string{"list"}, nil),
+		leadTime: prometheus.NewDesc("flow_lead_time_days",
+			"Lead time of the finished tasks, in days", []string{"list"}, nil),
+		wip: prometheus.NewDesc("flow_wip_tasks",
+			"Tasks in progress or blocked", []string{"list"}, nil),
+		throughput: prometheus.NewDesc("flow_throughput_tasks",
+			"Tasks finished within the last 7 days", []string{"list"}, nil),
+		timeToMerge: prometheus.NewDesc("merge_request_time_to_merge_days",
+			"Business days from the creation to the merge of the merge requests", []string{"project"}, nil),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cycleTime
+	ch <- c.leadTime
+	ch <- c.wip
+	ch <- c.throughput
+	ch <- c.timeToMerge
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	tasks, err := c.source.GetLatestTasks()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.cycleTime, err)
+		return
+	}
+	c.collectTasks(ch, tasks)
+
+	mrs, err := c.source.GetLatestMergeRequests()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.timeToMerge, err)
+		return
+	}
+	c.collectMergeRequests(ch, mrs)
+}
+
+// listStats are the flow metrics of the tasks of a list
+type listStats struct {
+	cycleTimes []float64
+	leadTimes  []float64
+	wip        int
+	throughput int
+}
+
+func (c *Collector) collectTasks(ch chan<- prometheus.Metric, tasks []data.TaskInfo) {
+	lists := map[string]*listStats{}
+	since := c.now().Add(-ThroughputWindow)
+
+	for _, task := range tasks {
+		stats, ok := lists[task.ListID]
+		if !ok {
+			stats = &listStats{}
+			lists[task.ListID] = stats
+		}
+
+		// The statuses of the workflow are keyed by their ClickUp names, like in metrics.Calculate
+		switch {
+		case c.wf.Statuses[task.Status].Done:
+			taskMetrics := metrics.Calculate(c.wf, []metrics.TaskInfo{{Id: task.Id, History: task.History}})[0].Metrics
+			stats.cycleTimes = append(stats.cycleTimes, float64(taskMetrics.CycleTime))
+			stats.leadTimes = append(stats.leadTimes, float64(taskMetrics.LeadTime))
+			if doneAt, ok := c.wf.DoneAt(task.Status, task.History); ok && doneAt.After(since) {
+				stats.throughput++
+			}
+		case c.wf.IsWorkInProgress(task.Status):
+			stats.wip++
+		}
+	}
+
+	for list, stats := range lists {
+		ch <- newHistogram(c.cycleTime, stats.cycleTimes, DayBuckets, list)
+		ch <- newHistogram(c.leadTime, stats.leadTimes, DayBuckets, list)
+		ch <- prometheus.MustNewConstMetric(c.wip, prometheus.GaugeValue, float64(stats.wip), list)
+		ch <- prometheus.MustNewConstMetric(c.throughput, prometheus.GaugeValue, float64(stats.throughput), list)
+	}
+}
+
+func (c *Collector) collectMergeRequests(ch chan<- prometheus.Metric, mrs []mergerequests.MergeRequest) {
+	projects := map[string][]float64{}
+	for _, mr := range mrs {
+		project := strconv.Itoa(mr.ProjectID)
+		projects[project] = append(projects[project], float64(mr.TimeToMerge))
+	}
+
+	for project, timesToMerge := range projects {
+		ch <- newHistogram(c.timeToMerge, timesToMerge, TimeToMergeBuckets, project)
+	}
+}
+
+// newHistogram creates a constant histogram of the values with the cumulative counts of the buckets
+func newHistogram(desc *prometheus.Desc, values []float64, buckets []float64, labels ...string) prometheus.Metric {
+	counts := make(map[float64]uint64, len(buckets))
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+		for _, bucket := range buckets {
+			if value <= bucket {
+				counts[bucket]++
+			}
+		}
+	}
+
+	return prometheus.MustNewConstHistogram(desc, uint64(len(values)), sum, counts, labels...)
+}
@@ -0,0 +1,79 @@
+package exporter
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeSource struct {
+	tasks []data.TaskInfo
+	mrs   []mergerequests.MergeRequest
+}
+
+func (f fakeSource) GetLatestTasks() ([]data.TaskInfo, error) { return f.tasks, nil }
+func (f fakeSource) GetLatestMergeRequests() ([]mergerequests.MergeRequest, error) {
+	return f.mrs, nil
+}
+
+func TestCollector(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	recently := strconv.FormatInt(now.Add(-48*time.Hour).UnixMilli(), 10)
+	longAgo := strconv.FormatInt(now.Add(-30*24*time.Hour).UnixMilli(), 10)
+
+	wf := metrics.Workflow{Statuses: map[string]metrics.Status{
+		"in development": {Name: "in development", InProgress: true, IsCycleTimeCalculable: true},
+		"blocked":        {Name: "blocked", Blocked: true},
+		"completed":      {Name: "completed", Done: true},
+		"code review":    {Name: "code review", IsCycleTimeCalculable: true},
+		"Closed":         {Name: "Closed", Done: true},
+	}}
+
+	task := func(id string, status string, since string) data.TaskInfo {
+		return data.TaskInfo{
+			TaskHeaderData: data.TaskHeaderData{Id: id, Status: status, ListID: "901"},
+			History: []data.History{
+				{Status: "in development", Time: 3 * 24 * 60},
+				{Status: status, Time: 0, Since: since},
+			},
+		}
+	}
+
+	collector := NewCollector(fakeSource{
+		tasks: []data.TaskInfo{
+			task("1", "completed", recently),
+			task("2", "completed", longAgo),
+			task("3", "in development", recently),
+			task("4", "blocked", recently),
+			// The statuses keep the case of their ClickUp names
+			task("5", "Closed", recently),
+			task("6", "code review", recently),
+		},
+		mrs: []mergerequests.MergeRequest{{ProjectID: 10, TimeToMerge: 1}, {ProjectID: 10, TimeToMerge: 6}},
+	}, wf)
+	collector.now = func() time.Time { return now }
+
+	expected := `
+# HELP flow_throughput_tasks Tasks finished within the last 7 days
+# TYPE flow_throughput_tasks gauge
+flow_throughput_tasks{list="901"} 2
+# HELP flow_wip_tasks Tasks in progress or blocked
+# TYPE flow_wip_tasks gauge
+flow_wip_tasks{list="901"} 3
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "flow_wip_tasks", "flow_throughput_tasks")
+	if err != nil {
+		t.Error(err)
+	}
+
+	count := testutil.CollectAndCount(collector, "flow_cycle_time_days", "merge_request_time_to_merge_days")
+	if count != 2 {
+		t.Errorf("Cantidad de histogramas incorrecta, se esperaba %d pero se obtuvo %d", 2, count)
+	}
+}
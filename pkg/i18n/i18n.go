@@ -0,0 +1,116 @@
+/*
+Package i18n translates the texts of the dashboard and the messages of the API errors.
+
+The messages are kept in a JSON catalog per language under locales, by message ID. A message can have fmt verbs,
+filled with the arguments given to T. Messages missing from a catalog fall back to the default language.
+
+Usage:
+
+	lang := i18n.Match(r.Header.Get("Accept-Language"))
+	title := i18n.T(lang, "dashboard.title")
+*/
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported languages
+const (
+	Spanish = "es"
+	English = "en"
+	Default = Spanish
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalogs has the messages of every supported language, by message ID
+var catalogs = mustLoadCatalogs(Spanish, English)
+
+func mustLoadCatalogs(languages ...string) map[string]map[string]string {
+	result := map[string]map[string]string{}
+	for _, lang := range languages {
+		content, err := locales.ReadFile("locales/" + lang + ".json")
+		if err != nil {
+			panic(err)
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(content, &catalog); err != nil {
+			panic(fmt.Sprintf("invalid catalog %s: %v", lang, err))
+		}
+		result[lang] = catalog
+	}
+	return result
+}
+
+// Supported reports whether there is a catalog for the language
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// T returns the message in the language, formatted with args. Unknown languages use the default one,
+// and unknown message IDs are returned as they are.
+func T(lang string, id string, args ...interface{}) string {
+	message, ok := catalogs[lang][id]
+	if !ok {
+		message, ok = catalogs[Default][id]
+	}
+	if !ok {
+		message = id
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Translator returns T bound to the language, for the templates
+func Translator(lang string) func(id string, args ...interface{}) string {
+	return func(id string, args ...interface{}) string {
+		return T(lang, id, args...)
+	}
+}
+
+// Match returns the supported language preferred by an Accept-Language header, e.g. "en-US,en;q=0.9,es;q=0.8",
+// or the default language if none of them is supported
+func Match(acceptLanguage string) string {
+	type preference struct {
+		lang    string
+		quality float64
+	}
+
+	preferences := []preference{}
+	for _, item := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		// Only the language matters, es-AR and es-ES use the same catalog
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if Supported(lang) && quality > 0 {
+			preferences = append(preferences, preference{lang: lang, quality: quality})
+		}
+	}
+	if len(preferences) == 0 {
+		return Default
+	}
+
+	// The order of the header breaks the ties
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	return preferences[0].lang
+}
@@ -0,0 +1,50 @@
+package i18n
+
+import "testing"
+
+func TestCatalogsHaveTheSameMessages(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for id := range catalogs[Default] {
+			if _, ok := catalog[id]; !ok {
+				t.Errorf("Falta el mensaje %s en el catálogo %s", id, lang)
+			}
+		}
+		for id := range catalog {
+			if _, ok := catalogs[Default][id]; !ok {
+				t.Errorf("El mensaje %s del catálogo %s no existe en el catálogo %s", id, lang, Default)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := map[string]string{
+		"":                        Default,
+		"en":                      English,
+		"en-US,en;q=0.9,es;q=0.8": English,
+		"es-AR,es;q=0.9,en;q=0.8": Spanish,
+		"fr-FR,fr;q=0.9,en;q=0.5": English,
+		"de":                      Default,
+		"es;q=0.2, en;q=0.7":      English,
+		"en;q=0":                  Default,
+		"en;q=invalid, es":        Spanish,
+	}
+
+	for header, expected := range cases {
+		if result := Match(header); result != expected {
+			t.Errorf("Idioma de %q incorrecto, se esperaba %s pero se obtuvo %s", header, expected, result)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if result := T(English, "unit.days", 3); result != "3 days" {
+		t.Errorf("Mensaje incorrecto, se esperaba %q pero se obtuvo %q", "3 days", result)
+	}
+	if result := T("fr", "unit.days", 3); result != "3 días" {
+		t.Errorf("Mensaje incorrecto, se esperaba %q pero se obtuvo %q", "3 días", result)
+	}
+	if result := T(English, "unknown.message"); result != "unknown.message" {
+		t.Errorf("Mensaje incorrecto, se esperaba %q pero se obtuvo %q", "unknown.message", result)
+	}
+}
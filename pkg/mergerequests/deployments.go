@@ -0,0 +1,146 @@
+package mergerequests
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	GitlabURLGetDeployments             = "https://gitlab.com/api/v4/projects/%d/deployments?environment=%s&order_by=updated_at&sort=asc&per_page=100"
+	GitlabURLGetDeploymentMergeRequests = "https://gitlab.com/api/v4/projects/%d/deployments/%d/merge_requests?per_page=%d&page=%d"
+	GitlabURLGetMergeRequestCommits     = "https://gitlab.com/api/v4/projects/%d/merge_requests/%d/commits?per_page=%d&page=%d"
+	DeploymentStatusSuccess             = "success"
+	DeploymentStatusFailed              = "failed"
+	DefaultProductionEnvironment        = "production"
+)
+
+// deploymentItemsPerPage is the number of merge requests or commits requested in every page
+const deploymentItemsPerPage = 100
+
+type Deployable struct {
+	FinishedAt string `json:"finished_at"`
+}
+
+type Deployment struct {
+	ID         int        `json:"id"`
+	IID        int        `json:"iid"`
+	ProjectID  int        `json:"project_id"`
+	Ref        string     `json:"ref"`
+	SHA        string     `json:"sha"`
+	Status     string     `json:"status"`
+	CreatedAt  string     `json:"created_at"`
+	UpdatedAt  string     `json:"updated_at"`
+	Deployable Deployable `json:"deployable"`
+}
+
+type Commit struct {
+	ID           string `json:"id"`
+	AuthoredDate string `json:"authored_date"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// FinishedAt returns when the deployment job finished, or its last update when GitLab doesn't report it
+func (d *Deployment) FinishedAt() time.Time {
+	if finishedAt, err := time.Parse(time.RFC3339, d.Deployable.FinishedAt); err == nil {
+		return finishedAt
+	}
+	updatedAt, _ := time.Parse(time.RFC3339, d.UpdatedAt)
+	return updatedAt
+}
+
+// GetDeploymentsBetween returns the successful and failed deployments of the project to the environment
+// updated between startDate and endDate (YYYY-MM-DD)
+func (c *GitlabClient) GetDeploymentsBetween(projectID int, environment string, startDate string, endDate string) ([]Deployment, error) {
+	var result []Deployment
+	page := 1
+
+	for {
+		query := url.Values{}
+		query.Add("updated_after", startDate+rangeStartSuffix)
+		query.Add("updated_before", endDate+rangeEndSuffix)
+		query.Add("page", strconv.Itoa(page))
+
+		log.Printf("Fetching deployments of project %d from date %s to %s (page %d)", projectID, startDate, endDate, page)
+		var deployments []Deployment
+		if err := c.getCachedJSON(fmt.Sprintf(GitlabURLGetDeployments, projectID, url.QueryEscape(environment))+"&"+query.Encode(), &deployments, c.CacheTTL.Short); err != nil {
+			return nil, err
+		}
+
+		for _, deployment := range deployments {
+			if deployment.Status != DeploymentStatusSuccess && deployment.Status != DeploymentStatusFailed {
+				continue
+			}
+			deployment.ProjectID = projectID
+			result = append(result, deployment)
+		}
+
+		if len(deployments) == 0 {
+			break
+		}
+
+		page++
+	}
+
+	return result, nil
+}
+
+// GetDeploymentMergeRequests returns the merge requests shipped for the first time by the deployment, page by page
+func (c *GitlabClient) GetDeploymentMergeRequests(projectID int, deploymentID int) ([]MergeRequest, error) {
+	var mergeRequests []MergeRequest
+
+	for page := 1; ; page++ {
+		var pageMergeRequests []MergeRequest
+		// The merge requests of a finished deployment don't change
+		pageURL := fmt.Sprintf(GitlabURLGetDeploymentMergeRequests, projectID, deploymentID, deploymentItemsPerPage, page)
+		if err := c.getCachedJSON(pageURL, &pageMergeRequests, c.CacheTTL.Long); err != nil {
+			return nil, err
+		}
+		mergeRequests = append(mergeRequests, pageMergeRequests...)
+		if len(pageMergeRequests) < deploymentItemsPerPage {
+			return mergeRequests, nil
+		}
+	}
+}
+
+// GetFirstCommitDate returns the authored date of the oldest commit of the merge request.
+// GitLab lists the newest commits first, so every page is read.
+func (c *GitlabClient) GetFirstCommitDate(projectID int, iid int) (time.Time, error) {
+	var commits []Commit
+
+	for page := 1; ; page++ {
+		var pageCommits []Commit
+		pageURL := fmt.Sprintf(GitlabURLGetMergeRequestCommits, projectID, iid, deploymentItemsPerPage, page)
+		if err := c.getCachedJSON(pageURL, &pageCommits, c.CacheTTL.Long); err != nil {
+			return time.Time{}, err
+		}
+		commits = append(commits, pageCommits...)
+		if len(pageCommits) < deploymentItemsPerPage {
+			break
+		}
+	}
+
+	return firstCommitDate(projectID, iid, commits)
+}
+
+// firstCommitDate returns the authored date of the oldest of the commits of the merge request
+func firstCommitDate(projectID int, iid int, commits []Commit) (time.Time, error) {
+	var first time.Time
+	for _, commit := range commits {
+		authoredDate, err := time.Parse(time.RFC3339, commit.AuthoredDate)
+		if err != nil {
+			continue
+		}
+		if first.IsZero() || authoredDate.Before(first) {
+			first = authoredDate
+		}
+	}
+
+	if first.IsZero() {
+		return time.Time{}, fmt.Errorf("merge request %d of project %d has no commits", iid, projectID)
+	}
+
+	return first, nil
+}
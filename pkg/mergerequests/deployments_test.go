@@ -0,0 +1,44 @@
+package mergerequests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// pagedTransport answers the requests of the pages of a GitLab list with the items of every page
+type pagedTransport struct {
+	pages map[string][]Commit
+}
+
+func (p pagedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, _ := json.Marshal(p.pages[r.URL.Query().Get("page")])
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body)), Header: http.Header{}, Request: r}, nil
+}
+
+func TestGetFirstCommitDate(t *testing.T) {
+	// GitLab lists the newest commits first, the oldest one is in the last page
+	newest := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	pages := map[string][]Commit{}
+	for i := 0; i < deploymentItemsPerPage+20; i++ {
+		page := fmt.Sprint(i/deploymentItemsPerPage + 1)
+		pages[page] = append(pages[page], Commit{AuthoredDate: newest.Add(-time.Duration(i) * time.Hour).Format(time.RFC3339)})
+	}
+
+	transport := http.DefaultTransport
+	http.DefaultTransport = pagedTransport{pages: pages}
+	defer func() { http.DefaultTransport = transport }()
+
+	first, err := NewGitlabClient("1", "", "token").GetFirstCommitDate(10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := newest.Add(-time.Duration(deploymentItemsPerPage+19) * time.Hour)
+	if !first.Equal(expected) {
+		t.Errorf("Primer commit incorrecto, se esperaba %v pero se obtuvo %v", expected, first)
+	}
+}
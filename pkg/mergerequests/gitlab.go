@@ -1,91 +1,160 @@
 package mergerequests
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/cache"
+)
+
+const (
+	GitlabURLGetMergeRequests     = "https://gitlab.com/api/v4/groups/%s/merge_requests?scope=all&search=%s&in=title"
+	GitlabURLGetMergeRequestDiffs = "https://gitlab.com/api/v4/projects/%d/merge_requests/%d/diffs?per_page=%d&page=%d"
+)
+
+// Errors returned by the GitLab client, wrapped with the details of the failed request
+var (
+	ErrUnauthorized = errors.New("gitlab token is expired or is not valid")
+	ErrForbidden    = errors.New("gitlab token doesn't have access")
+	ErrNotFound     = errors.New("resource not found")
+	ErrRateLimited  = errors.New("gitlab rate limit exceeded")
+	ErrUpstream     = errors.New("gitlab error")
 )
 
+// DateField is the merge request date used to decide whether it falls into a date range
+type DateField string
+
+// Date fields supported when filtering merge requests
+const (
+	DateFieldCreated DateField = "created"
+	DateFieldMerged  DateField = "merged"
+	DateFieldUpdated DateField = "updated"
+)
+
+// maxConcurrentMergeRequests is the number of merge requests whose diffs and pipelines are retrieved at the same time
+const maxConcurrentMergeRequests = 5
+
+// diffsPerPage is the number of files requested in every page of the diffs of a merge request
+const diffsPerPage = 100
+
+// DisplayDateFormat is the layout of CreatedAt and MergedAt once the merge requests are returned by the client
+const DisplayDateFormat = "2006-01-02 15:04"
+
 const (
-	GitlabURLGetMergeRequests       = "https://gitlab.com/api/v4/groups/%s/merge_requests?scope=all&created_after=%sT00:00:00.000Z&created_before=%sT23:59:59.999Z&search=%s&in=title&state=merged"
-	GitlabURLGetMergeRequestChanges = "https://gitlab.com/api/v4/projects/%d/merge_requests/%d/changes"
+	rangeStartSuffix = "T00:00:00.000Z"
+	rangeEndSuffix   = "T23:59:59.999Z"
 )
 
+// ParseDateField converts a string into a DateField, falling back to DateFieldMerged
+// when the value is empty or unknown.
+func ParseDateField(value string) DateField {
+	switch DateField(value) {
+	case DateFieldCreated, DateFieldUpdated:
+		return DateField(value)
+	default:
+		return DateFieldMerged
+	}
+}
+
 type Change struct {
-	Diff string `json:"diff"`
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	TooLarge    bool   `json:"too_large"`
+	Collapsed   bool   `json:"collapsed"` // GitLab truncated the diff of the file
 }
 type MergeRequestChange struct {
 	Changes []Change `json:"changes"`
 }
 type MergeRequest struct {
-	ID          int    `json:"id"`
-	IID         int    `json:"iid"`
-	ProjectID   int    `json:"project_id"`
-	Title       string `json:"title"`
-	CreatedAt   string `json:"created_at"`
-	MergedAt    string `json:"merged_at"`
-	TimeToMerge int    `json:"time_to_merge"`
-	Size        int    `json:"size"`
-	WebUrl      string `json:"web_url"`
+	ID            int           `json:"id"`
+	IID           int           `json:"iid"`
+	ProjectID     int           `json:"project_id"`
+	Title         string        `json:"title"`
+	Description   string        `json:"description"`
+	SourceBranch  string        `json:"source_branch"`
+	CreatedAt     string        `json:"created_at"`
+	MergedAt      string        `json:"merged_at"`
+	State         string        `json:"state"`
+	TimeToMerge   int           `json:"time_to_merge"`
+	Size          int           `json:"size"`
+	Additions     int           `json:"additions"`
+	Deletions     int           `json:"deletions"`
+	FilesChanged  int           `json:"files_changed"`
+	DiffTruncated bool          `json:"diff_truncated"`
+	MissingDiffs  int           `json:"missing_diffs,omitempty"` // Files whose diff GitLab didn't return, not counted in Size
+	WebUrl        string        `json:"web_url"`
+	TaskIDs       []string      `json:"task_ids,omitempty"`
+	Pipelines     PipelineStats `json:"pipelines"`
 }
 
 type GitlabClient struct {
-	GroupID string
-	Team    string
-	Token   string
+	GroupID       string
+	Team          string
+	Token         string
+	ExcludedPaths []string    // Globs of the files that don't count for the merge request size
+	Cache         cache.Store // Optional store for the responses of GitLab
+	CacheTTL      cache.TTL   // Short for lists that can change, long for merged merge requests
+	Refresh       bool        // Ignore the cached responses, the new ones are stored anyway
 }
 
 func NewGitlabClient(groupID, team, token string) *GitlabClient {
 	return &GitlabClient{
-		GroupID: groupID,
-		Team:    team,
-		Token:   token,
+		GroupID:       groupID,
+		Team:          team,
+		Token:         token,
+		ExcludedPaths: DefaultExcludedPaths,
+		CacheTTL:      cache.DefaultTTL,
 	}
 }
 
+// GetMergeRequestsMergedBetween returns the merge requests merged between startDate and endDate (YYYY-MM-DD)
 func (c *GitlabClient) GetMergeRequestsMergedBetween(startDate string, endDate string) ([]MergeRequest, error) {
+	return c.GetMergeRequestsBetween(startDate, endDate, DateFieldMerged)
+}
+
+// GetMergeRequestsBetween returns the merge requests whose dateField falls between startDate and endDate (YYYY-MM-DD).
+// Only merged merge requests are returned for DateFieldMerged, in any state for the other fields.
+// GitLab can't filter by merge date, so for DateFieldMerged the request is pre-filtered with updated_after
+// and the merge date is checked on the client side.
+func (c *GitlabClient) GetMergeRequestsBetween(startDate string, endDate string, dateField DateField) ([]MergeRequest, error) {
 	var allMergeRequests []MergeRequest
 	page := 1
 
 	for {
-		url := fmt.Sprintf(GitlabURLGetMergeRequests, c.GroupID, startDate, endDate, c.Team)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("PRIVATE-TOKEN", c.Token)
-
-		query := req.URL.Query()
+		query := url.Values{}
+		addDateRange(query, startDate, endDate, dateField)
 		query.Add("page", strconv.Itoa(page))
-		req.URL.RawQuery = query.Encode()
+		pageURL := fmt.Sprintf(GitlabURLGetMergeRequests, c.GroupID, url.QueryEscape(c.Team)) + "&" + query.Encode()
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
 		log.Printf("Fetching data from GitLab from date %s to %s (page %d)", startDate, endDate, page)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusNotFound {
-			return nil, errors.New("resource not found")
-		}
-
 		var mergeRequests []MergeRequest
-		if err := json.NewDecoder(resp.Body).Decode(&mergeRequests); err != nil {
-			log.Println(err)
+		if err := c.getCachedJSON(pageURL, &mergeRequests, c.CacheTTL.Short); err != nil {
 			return nil, err
 		}
 
-		allMergeRequests = append(allMergeRequests, mergeRequests...)
+		for _, mr := range mergeRequests {
+			if dateField == DateFieldMerged && !isBetween(mr.MergedAt, startDate, endDate) {
+				continue
+			}
+			allMergeRequests = append(allMergeRequests, mr)
+		}
 
 		if len(mergeRequests) == 0 {
 			// No more merge requests, exit the loop
@@ -96,26 +165,22 @@ func (c *GitlabClient) GetMergeRequestsMergedBetween(startDate string, endDate s
 	}
 
 	var result []MergeRequest
+	var mu sync.Mutex
 	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrentMergeRequests)
 
 	for _, mr := range allMergeRequests {
 		mrCopy := mr
 		wg.Add(1)
 		go func(mr MergeRequest) {
 			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
 
-			change, _ := c.GetMergeRequestChanges(mr.ProjectID, mr.IID)
-			result = append(result, MergeRequest{
-				ID:          mr.ID,
-				IID:         mr.IID,
-				ProjectID:   mr.ProjectID,
-				Title:       mr.Title,
-				Size:        calculateNetChangesSize(change),
-				TimeToMerge: getTimeToMerge(&mr),
-				CreatedAt:   formatDate(mr.CreatedAt),
-				MergedAt:    formatDate(mr.MergedAt),
-				WebUrl:      mr.WebUrl,
-			})
+			details := c.getMergeRequestDetails(mr)
+			mu.Lock()
+			defer mu.Unlock()
+			result = append(result, details)
 		}(mrCopy)
 	}
 	wg.Wait()
@@ -123,6 +188,105 @@ func (c *GitlabClient) GetMergeRequestsMergedBetween(startDate string, endDate s
 	return result, nil
 }
 
+// getMergeRequestDetails retrieves the size and the pipelines of a merge request.
+// Merged merge requests don't change, so their result is cached for the long TTL, the others for the short one.
+func (c *GitlabClient) getMergeRequestDetails(mr MergeRequest) MergeRequest {
+	key := c.detailsKey(mr)
+
+	var details MergeRequest
+	if c.Cache != nil && !c.Refresh && c.Cache.Get(key, &details) {
+		return details
+	}
+
+	change, err := c.GetMergeRequestChanges(mr.ProjectID, mr.IID)
+	stats := calculateDiffStats(change, c.ExcludedPaths)
+	if err != nil {
+		// Part of the diff is missing, the size is only a lower bound
+		log.Println(err)
+		stats.Truncated = true
+	}
+	openedAt, _ := time.Parse(time.RFC3339, mr.CreatedAt)
+	mergedAt, _ := time.Parse(time.RFC3339, mr.MergedAt)
+	pipelines, pipelinesErr := c.GetMergeRequestPipelineStats(mr.ProjectID, mr.IID, openedAt, mergedAt)
+	if pipelinesErr != nil {
+		log.Println(pipelinesErr)
+	}
+
+	details = MergeRequest{
+		ID:            mr.ID,
+		IID:           mr.IID,
+		ProjectID:     mr.ProjectID,
+		Title:         mr.Title,
+		Description:   mr.Description,
+		SourceBranch:  mr.SourceBranch,
+		Size:          stats.Additions + stats.Deletions,
+		Additions:     stats.Additions,
+		Deletions:     stats.Deletions,
+		FilesChanged:  stats.Files,
+		DiffTruncated: stats.Truncated,
+		MissingDiffs:  stats.MissingDiffs,
+		TimeToMerge:   getTimeToMerge(&mr),
+		CreatedAt:     formatDate(mr.CreatedAt),
+		MergedAt:      formatDate(mr.MergedAt),
+		State:         mr.State,
+		WebUrl:        mr.WebUrl,
+		Pipelines:     pipelines,
+	}
+
+	// Incomplete details are not cached, so they are retrieved again next time
+	if c.Cache != nil && err == nil && pipelinesErr == nil {
+		ttl := c.CacheTTL.Long
+		if mr.MergedAt == "" {
+			ttl = c.CacheTTL.Short
+		}
+		c.Cache.Set(key, details, ttl)
+	}
+
+	return details
+}
+
+// detailsKey returns the cache key of the details of a merge request. The size depends on the excluded paths,
+// so they are part of the key and a change of the configuration doesn't return stale sizes.
+func (c *GitlabClient) detailsKey(mr MergeRequest) string {
+	sum := sha256.Sum256([]byte(strings.Join(c.ExcludedPaths, "\n")))
+	return fmt.Sprintf("gitlab:merge_request:%d:%d:%x", mr.ProjectID, mr.IID, sum[:8])
+}
+
+// addDateRange adds the GitLab query parameters that restrict the merge requests to the date range
+func addDateRange(query url.Values, startDate string, endDate string, dateField DateField) {
+	switch dateField {
+	case DateFieldCreated:
+		query.Add("created_after", startDate+rangeStartSuffix)
+		query.Add("created_before", endDate+rangeEndSuffix)
+	case DateFieldUpdated:
+		query.Add("updated_after", startDate+rangeStartSuffix)
+		query.Add("updated_before", endDate+rangeEndSuffix)
+	default:
+		// A merge request is always updated when it gets merged, so anything merged
+		// inside the range has been updated after its start
+		query.Add("state", "merged")
+		query.Add("updated_after", startDate+rangeStartSuffix)
+	}
+}
+
+// isBetween reports whether the RFC3339 date is inside the range defined by startDate and endDate (YYYY-MM-DD), both inclusive
+func isBetween(date string, startDate string, endDate string) bool {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse(time.RFC3339, startDate+rangeStartSuffix)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(time.RFC3339, endDate+rangeEndSuffix)
+	if err != nil {
+		return false
+	}
+
+	return !t.Before(start) && !t.After(end)
+}
+
 // sortByCreatedAt sorts a slice of MergeRequest structs by their CreatedAt dates in ascending order.
 // It takes a slice of MergeRequest structs as input and sorts it in-place.
 func sortByCreatedAt(mrs []MergeRequest) {
@@ -131,11 +295,48 @@ func sortByCreatedAt(mrs []MergeRequest) {
 	})
 }
 
+// GetMergeRequestChanges retrieves the diffs of all the files changed by a merge request, page by page,
+// so large merge requests are not truncated. The diff of a single file is still omitted when it is too large.
 func (c *GitlabClient) GetMergeRequestChanges(projectID int, iid int) (MergeRequestChange, error) {
-	url := fmt.Sprintf(GitlabURLGetMergeRequestChanges, projectID, iid)
+	var changes MergeRequestChange
+
+	for page := 1; ; page++ {
+		var diffs []Change
+		if err := c.getJSON(fmt.Sprintf(GitlabURLGetMergeRequestDiffs, projectID, iid, diffsPerPage, page), &diffs); err != nil {
+			return changes, err
+		}
+
+		changes.Changes = append(changes.Changes, diffs...)
+		if len(diffs) < diffsPerPage {
+			return changes, nil
+		}
+	}
+}
+
+// getCachedJSON is getJSON, keeping the response in the cache for the ttl
+func (c *GitlabClient) getCachedJSON(url string, v interface{}, ttl time.Duration) error {
+	if c.Cache == nil {
+		return c.getJSON(url, v)
+	}
+
+	key := "gitlab:" + url
+	if !c.Refresh && c.Cache.Get(key, v) {
+		return nil
+	}
+
+	if err := c.getJSON(url, v); err != nil {
+		return err
+	}
+
+	c.Cache.Set(key, v, ttl)
+	return nil
+}
+
+// getJSON performs an authenticated GET request against the GitLab API and decodes the JSON response into v
+func (c *GitlabClient) getJSON(url string, v interface{}) error {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return MergeRequestChange{}, err
+		return err
 	}
 	req.Header.Set("PRIVATE-TOKEN", c.Token)
 
@@ -143,63 +344,72 @@ func (c *GitlabClient) GetMergeRequestChanges(projectID int, iid int) (MergeRequ
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Println(err)
-		return MergeRequestChange{}, err
+		return fmt.Errorf("%w: %v", ErrUpstream, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Println("Error reading response body:", err)
-			return MergeRequestChange{}, err
-		}
-		bodyString := string(bodyBytes)
-		log.Println(bodyString)
-		return MergeRequestChange{}, errors.New(bodyString)
+	if err := checkResponse(resp); err != nil {
+		return err
 	}
 
-	var changes MergeRequestChange
-
-	if err := json.NewDecoder(resp.Body).Decode(&changes); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
 		log.Println(err)
-		return MergeRequestChange{}, err
+		return fmt.Errorf("%w: %v", ErrUpstream, err)
 	}
 
-	return changes, nil
+	return nil
 }
 
-func calculateNetChangesSize(change MergeRequestChange) int {
-	netAdditions := 0
-	netDeletions := 0
-	for _, change := range change.Changes {
-		lines := strings.Split(change.Diff, "\n")
-
-		for _, line := range lines {
-			if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
-				netAdditions++
-			} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
-				netDeletions++
-			}
-		}
+// checkResponse converts the error status codes of the GitLab API into the errors of this package,
+// including the body of the response
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
 	}
 
-	netChangesSize := netAdditions + netDeletions
-	return netChangesSize
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Println("Error reading response body:", err)
+	}
+	bodyString := string(bodyBytes)
+	log.Println(bodyString)
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %s", ErrUnauthorized, bodyString)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrForbidden, bodyString)
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, bodyString)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %s", ErrRateLimited, bodyString)
+	default:
+		return fmt.Errorf("%w: GitLab responded %d: %s", ErrUpstream, resp.StatusCode, bodyString)
+	}
 }
 
+// getTimeToMerge returns the business days from the creation to the merge of the merge request, 0 if it isn't merged
 func getTimeToMerge(mr *MergeRequest) int {
+	if mr.MergedAt == "" {
+		return 0
+	}
 	createdAt, _ := time.Parse("2006-01-02T15:04:05.999999Z07:00", mr.CreatedAt)
 	mergedAt, _ := time.Parse("2006-01-02T15:04:05.999999Z07:00", mr.MergedAt)
 
-	timeDifference := mergedAt.Sub(createdAt)
+	return businessDaysBetween(createdAt, mergedAt)
+}
+
+// businessDaysBetween returns the number of whole days between from and to, excluding weekends
+func businessDaysBetween(from time.Time, to time.Time) int {
+	timeDifference := to.Sub(from)
 	daysDifference := int(timeDifference.Hours() / 24)
 
-	weekends := (daysDifference + int(createdAt.Weekday()) + 1) / 7 * 2
+	weekends := (daysDifference + int(from.Weekday()) + 1) / 7 * 2
 
-	if createdAt.Weekday() == time.Sunday {
+	if from.Weekday() == time.Sunday {
 		weekends--
 	}
-	if mergedAt.Weekday() == time.Saturday {
+	if to.Weekday() == time.Saturday {
 		weekends--
 	}
 
@@ -213,6 +423,11 @@ func (mr *MergeRequest) GetSize() int {
 }
 
 func formatDate(dateStr string) string {
+	if dateStr == "" {
+		// Not merged yet
+		return ""
+	}
+
 	// Parse the date string
 	parsedTime, err := time.Parse(time.RFC3339, dateStr)
 	if err != nil {
@@ -220,7 +435,7 @@ func formatDate(dateStr string) string {
 	}
 
 	// Format the date as "YYYY-MM-DD HH:MM"
-	formattedDate := parsedTime.Format("2006-01-02 15:04")
+	formattedDate := parsedTime.Format(DisplayDateFormat)
 
 	return formattedDate
 }
@@ -0,0 +1,71 @@
+package mergerequests
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestIsBetween(t *testing.T) {
+	cases := []struct {
+		date     string
+		expected bool
+	}{
+		{"2023-07-01T00:00:00.000Z", true},
+		{"2023-07-15T10:30:00.000-03:00", true},
+		{"2023-07-31T23:59:59.000Z", true},
+		{"2023-06-30T23:59:59.000Z", false},
+		{"2023-08-01T00:00:00.000Z", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		if got := isBetween(c.date, "2023-07-01", "2023-07-31"); got != c.expected {
+			t.Errorf("isBetween(%q) incorrecto, se esperaba %t pero se obtuvo %t", c.date, c.expected, got)
+		}
+	}
+}
+
+func TestAddDateRange(t *testing.T) {
+	query := url.Values{}
+	addDateRange(query, "2023-07-01", "2023-07-31", DateFieldMerged)
+
+	if query.Get("updated_after") != "2023-07-01T00:00:00.000Z" {
+		t.Errorf("updated_after incorrecto, se obtuvo %q", query.Get("updated_after"))
+	}
+	if query.Has("updated_before") || query.Has("created_after") || query.Get("state") != "merged" {
+		t.Errorf("Filtro por merge incorrecto, se obtuvo %q", query.Encode())
+	}
+
+	query = url.Values{}
+	addDateRange(query, "2023-07-01", "2023-07-31", DateFieldCreated)
+
+	if query.Get("created_after") != "2023-07-01T00:00:00.000Z" || query.Get("created_before") != "2023-07-31T23:59:59.999Z" {
+		t.Errorf("Filtro por creación incorrecto, se obtuvo %q", query.Encode())
+	}
+	if query.Has("state") {
+		t.Errorf("El filtro por creación no debe limitar el estado, se obtuvo %q", query.Encode())
+	}
+}
+
+func TestParseDateField(t *testing.T) {
+	if ParseDateField("") != DateFieldMerged {
+		t.Errorf("Se esperaba %q por defecto", DateFieldMerged)
+	}
+	if ParseDateField("created") != DateFieldCreated {
+		t.Errorf("Se esperaba %q", DateFieldCreated)
+	}
+	if ParseDateField("closed") != DateFieldMerged {
+		t.Errorf("Se esperaba %q para valores desconocidos", DateFieldMerged)
+	}
+}
+
+func TestDetailsKey(t *testing.T) {
+	mr := MergeRequest{ProjectID: 1, IID: 2}
+	c := NewGitlabClient("1", "CORE", "token")
+	defaultKey := c.detailsKey(mr)
+
+	c.ExcludedPaths = []string{"go.sum"}
+	if c.detailsKey(mr) == defaultKey {
+		t.Errorf("La clave de cache no cambió con los paths excluidos: %s", defaultKey)
+	}
+}
@@ -0,0 +1,144 @@
+package mergerequests
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// prefixPattern matches the prefixes of the custom ids of ClickUp, like CORE
+	prefixPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+	// clickUpIDPattern matches the ClickUp ids with the prefix used by the GitLab integration or a hash, like CU-85zt8cyjd or #85zt8cyjd
+	clickUpIDPattern = regexp.MustCompile(`(?i)(CU-|#)([0-9a-z]+)`)
+)
+
+// ParseTaskIDs returns the ClickUp task ids and custom ids mentioned in the title, source branch or description
+// of the merge request, without repetitions. Custom ids are returned in upper case and task ids in lower case.
+// Only the custom ids with one of the prefixes are matched, so "UTF-8" is not a ticket, and task ids need the CU-
+// prefix or the shape of a ClickUp id after a hash, so "#12" is not a ticket either.
+// Ids are matched as whole words, so "CU-85zt8cyjd_fix" mentions 85zt8cyjd and "CORE-1234" doesn't mention CORE-123.
+func ParseTaskIDs(mr MergeRequest, prefixes ...string) []string {
+	customIDPattern := customIDPattern(prefixes)
+
+	ids := []string{}
+	add := func(id string) {
+		for _, existing := range ids {
+			if existing == id {
+				return
+			}
+		}
+		ids = append(ids, id)
+	}
+
+	for _, text := range []string{mr.Title, mr.SourceBranch, mr.Description} {
+		if customIDPattern != nil {
+			for _, match := range customIDPattern.FindAllStringIndex(text, -1) {
+				if isWord(text, match[0], match[1]) {
+					add(strings.ToUpper(text[match[0]:match[1]]))
+				}
+			}
+		}
+		for _, match := range clickUpIDPattern.FindAllStringSubmatchIndex(text, -1) {
+			id := strings.ToLower(text[match[4]:match[5]])
+			if !isWord(text, match[0], match[1]) || text[match[2]:match[3]] == "#" && !isClickUpID(id) {
+				continue
+			}
+			add(id)
+		}
+	}
+	return ids
+}
+
+// customIDPattern returns the pattern of the custom ids with the prefixes, like CORE-123, or nil if none of the
+// prefixes is valid. The trailing dash of the prefixes is optional.
+func customIDPattern(prefixes []string) *regexp.Regexp {
+	quoted := []string{}
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(strings.TrimSpace(prefix), "-")
+		if prefixPattern.MatchString(prefix) {
+			quoted = append(quoted, regexp.QuoteMeta(prefix))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)-[0-9]+`)
+}
+
+// isClickUpID reports whether the id has the shape of the ids ClickUp generates: 7 to 9 letters and digits,
+// with at least one of each, like 85zt8cyjd
+func isClickUpID(id string) bool {
+	if len(id) < 7 || len(id) > 9 {
+		return false
+	}
+	return strings.ContainsAny(id, "0123456789") && strings.ContainsAny(id, "abcdefghijklmnopqrstuvwxyz")
+}
+
+// isWord reports whether text[start:end] is not preceded nor followed by a letter or a digit
+func isWord(text string, start int, end int) bool {
+	return (start == 0 || !isAlphanumeric(text[start-1])) && (end == len(text) || !isAlphanumeric(text[end]))
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// LinkToTask returns the merge requests whose TaskIDs, set with ParseTaskIDs, include any of the task ids.
+// mrs is not modified.
+func LinkToTask(mrs []MergeRequest, ids ...string) []MergeRequest {
+	linked := []MergeRequest{}
+	for _, mr := range mrs {
+		if mr.mentions(ids...) {
+			linked = append(linked, mr)
+		}
+	}
+	return linked
+}
+
+func (mr *MergeRequest) mentions(ids ...string) bool {
+	for _, id := range ids {
+		id = strings.TrimPrefix(id, "#")
+		if id == "" {
+			continue
+		}
+		for _, taskID := range mr.TaskIDs {
+			if strings.EqualFold(taskID, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReviewTime returns the calendar days, rounded up, between the first of the merge requests being opened and
+// the last one being merged, the same unit as the cycle time of the tasks
+func ReviewTime(mrs []MergeRequest) int {
+	var firstCreated, lastMerged time.Time
+	for _, mr := range mrs {
+		createdAt, err := time.Parse(DisplayDateFormat, mr.CreatedAt)
+		if err != nil {
+			continue
+		}
+		mergedAt, err := time.Parse(DisplayDateFormat, mr.MergedAt)
+		if err != nil {
+			continue
+		}
+		if firstCreated.IsZero() || createdAt.Before(firstCreated) {
+			firstCreated = createdAt
+		}
+		if mergedAt.After(lastMerged) {
+			lastMerged = mergedAt
+		}
+	}
+
+	if firstCreated.IsZero() || lastMerged.IsZero() {
+		return 0
+	}
+
+	if !lastMerged.After(firstCreated) {
+		return 0
+	}
+	return int(math.Ceil(lastMerged.Sub(firstCreated).Hours() / 24))
+}
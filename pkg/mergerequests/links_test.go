@@ -0,0 +1,63 @@
+package mergerequests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTaskIDs(t *testing.T) {
+	cases := []struct {
+		mr       MergeRequest
+		expected []string
+	}{
+		{MergeRequest{Title: "PRGA-7 Encode the names as UTF-8", Description: "Checksum with SHA-256, dates as ISO-8601"}, []string{"PRGA-7"}},
+		{MergeRequest{Title: "Fix #12", SourceBranch: "fix-12"}, []string{}},
+		{MergeRequest{Title: "OTHER-5 Add login"}, []string{}},
+		{MergeRequest{Title: "CORE-123 Add login"}, []string{"CORE-123"}},
+		{MergeRequest{Title: "[core-123] Add login", Description: "Also CORE-124 and CORE-123"}, []string{"CORE-123", "CORE-124"}},
+		{MergeRequest{Title: "CORE-1234 Add login"}, []string{"CORE-1234"}},
+		{MergeRequest{SourceBranch: "CU-85zt8cyjd_add-login"}, []string{"85zt8cyjd"}},
+		{MergeRequest{Description: "Closes #85ZT8CYJD"}, []string{"85zt8cyjd"}},
+		{MergeRequest{Title: "Add login", SourceBranch: "feature/login"}, []string{}},
+	}
+
+	for _, c := range cases {
+		if got := ParseTaskIDs(c.mr, "CORE", "PRGA-", ""); !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("ParseTaskIDs(%+v) incorrecto, se esperaba %v pero se obtuvo %v", c.mr, c.expected, got)
+		}
+	}
+}
+
+func TestLinkToTaskAndReviewTime(t *testing.T) {
+	mrs := []MergeRequest{
+		{IID: 1, Title: "CORE-123 Backend", CreatedAt: "2023-07-03 10:00", MergedAt: "2023-07-04 10:00"},
+		{IID: 2, Title: "core-123 Frontend", CreatedAt: "2023-07-05 10:00", MergedAt: "2023-07-10 12:00"},
+		{IID: 3, Title: "CORE-1234 Other", CreatedAt: "2023-07-03 10:00", MergedAt: "2023-07-03 12:00"},
+	}
+	for i := range mrs {
+		mrs[i].TaskIDs = ParseTaskIDs(mrs[i], "CORE")
+	}
+
+	linked := LinkToTask(mrs, "CORE-123", "85zt8cyjd")
+
+	if len(linked) != 2 || linked[0].IID != 1 || linked[1].IID != 2 {
+		t.Fatalf("Se esperaban los MRs 1 y 2 vinculados pero se obtuvo %+v", linked)
+	}
+	if len(mrs[0].TaskIDs) != 1 || len(mrs[2].TaskIDs) != 1 || mrs[2].TaskIDs[0] != "CORE-1234" {
+		t.Errorf("TaskIDs modificados: %v %v", mrs[0].TaskIDs, mrs[2].TaskIDs)
+	}
+
+	// From Monday 3rd 10:00 to Monday 10th 12:00 there are 7 days and 2 hours, the same unit as the cycle time
+	if got := ReviewTime(linked); got != 8 {
+		t.Errorf("Review Time incorrecto, se esperaba 8 pero se obtuvo %d", got)
+	}
+}
+
+func TestParseTaskIDsWithoutPrefixes(t *testing.T) {
+	mr := MergeRequest{Title: "CORE-123 Add login", SourceBranch: "CU-85zt8cyjd_add-login"}
+
+	expected := []string{"85zt8cyjd"}
+	if got := ParseTaskIDs(mr); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseTaskIDs incorrecto, se esperaba %v pero se obtuvo %v", expected, got)
+	}
+}
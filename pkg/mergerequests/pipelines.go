@@ -0,0 +1,225 @@
+package mergerequests
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	GitlabURLGetMergeRequestPipelines = "https://gitlab.com/api/v4/projects/%d/merge_requests/%d/pipelines?per_page=%d&page=%d"
+	GitlabURLGetPipeline              = "https://gitlab.com/api/v4/projects/%d/pipelines/%d"
+	GitlabURLGetPipelineJobs          = "https://gitlab.com/api/v4/projects/%d/pipelines/%d/jobs?include_retried=true&per_page=%d&page=%d"
+	JobStatusSuccess                  = "success"
+	JobStatusFailed                   = "failed"
+)
+
+// pipelinesPerPage is the number of pipelines or jobs requested in every page
+const pipelinesPerPage = 100
+
+type Pipeline struct {
+	ID             int     `json:"id"`
+	SHA            string  `json:"sha"`
+	Status         string  `json:"status"`
+	Duration       int     `json:"duration"`        // Seconds
+	QueuedDuration float64 `json:"queued_duration"` // Seconds
+	CreatedAt      string  `json:"created_at"`
+	FinishedAt     string  `json:"finished_at"`
+}
+
+type Job struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Stage     string  `json:"stage"`
+	Status    string  `json:"status"`
+	Duration  float64 `json:"duration"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// PipelineStats summarizes the CI activity of a merge request
+type PipelineStats struct {
+	Pipelines      int      `json:"pipelines"`
+	Duration       int      `json:"duration"`             // Seconds running pipelines
+	QueuedDuration int      `json:"queued_duration"`      // Seconds waiting for a runner
+	Retries        int      `json:"retries"`              // Jobs run again within the same pipeline
+	FlakyJobs      []string `json:"flaky_jobs"`           // Jobs that failed and then passed within the same pipeline
+	CIShare        float64  `json:"ci_share"`             // Percentage of the time to merge with a pipeline queued or running
+	Incomplete     bool     `json:"incomplete,omitempty"` // Some pipelines couldn't be retrieved and are missing from the stats
+}
+
+// GetMergeRequestPipelineStats retrieves the pipelines of the merge request and their jobs, and summarizes them.
+// openedAt and mergedAt are used to compute the share of the time to merge with a pipeline queued or running.
+// When some pipelines can't be retrieved, the stats of the rest are returned along with the error.
+func (c *GitlabClient) GetMergeRequestPipelineStats(projectID int, iid int, openedAt time.Time, mergedAt time.Time) (PipelineStats, error) {
+	stats := PipelineStats{
+		FlakyJobs: []string{},
+	}
+
+	var pipelines []Pipeline
+	for page := 1; ; page++ {
+		var pagePipelines []Pipeline
+		if err := c.getJSON(fmt.Sprintf(GitlabURLGetMergeRequestPipelines, projectID, iid, pipelinesPerPage, page), &pagePipelines); err != nil {
+			return stats, err
+		}
+		pipelines = append(pipelines, pagePipelines...)
+		if len(pagePipelines) < pipelinesPerPage {
+			break
+		}
+	}
+
+	var errs []error
+	intervals := []interval{}
+	for _, p := range pipelines {
+		pipeline, jobs, err := c.getPipeline(projectID, p.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		stats.Pipelines++
+		stats.Duration += pipeline.Duration
+		stats.QueuedDuration += int(pipeline.QueuedDuration)
+		if pipelineInterval, ok := pipeline.interval(); ok {
+			intervals = append(intervals, pipelineInterval)
+		}
+
+		retries, flakyJobs := analyzeJobs(jobs)
+		stats.Retries += retries
+		stats.FlakyJobs = appendUnique(stats.FlakyJobs, flakyJobs...)
+	}
+
+	if mergedAt.After(openedAt) {
+		ciTime := unionDuration(intervals, interval{openedAt, mergedAt})
+		stats.CIShare = ciTime.Seconds() * 100 / mergedAt.Sub(openedAt).Seconds()
+	}
+
+	if len(errs) > 0 {
+		stats.Incomplete = true
+		return stats, fmt.Errorf("%d of %d pipelines of merge request %d of project %d missing: %w",
+			len(errs), len(pipelines), iid, projectID, errors.Join(errs...))
+	}
+	return stats, nil
+}
+
+// getPipeline retrieves the details of a pipeline and all its jobs, including the retried ones
+func (c *GitlabClient) getPipeline(projectID int, pipelineID int) (Pipeline, []Job, error) {
+	var pipeline Pipeline
+	if err := c.getJSON(fmt.Sprintf(GitlabURLGetPipeline, projectID, pipelineID), &pipeline); err != nil {
+		return Pipeline{}, nil, err
+	}
+
+	var jobs []Job
+	for page := 1; ; page++ {
+		var pageJobs []Job
+		if err := c.getJSON(fmt.Sprintf(GitlabURLGetPipelineJobs, projectID, pipelineID, pipelinesPerPage, page), &pageJobs); err != nil {
+			return Pipeline{}, nil, err
+		}
+		jobs = append(jobs, pageJobs...)
+		if len(pageJobs) < pipelinesPerPage {
+			return pipeline, jobs, nil
+		}
+	}
+}
+
+// interval is a period of time between two instants
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+// interval returns the period since the pipeline was created, including the time queued, until it finished
+func (p Pipeline) interval() (interval, bool) {
+	createdAt, err := time.Parse(time.RFC3339, p.CreatedAt)
+	if err != nil {
+		return interval{}, false
+	}
+	finishedAt, err := time.Parse(time.RFC3339, p.FinishedAt)
+	if err != nil || finishedAt.Before(createdAt) {
+		return interval{}, false
+	}
+	return interval{createdAt, finishedAt}, true
+}
+
+// unionDuration returns the time within the window covered by any of the intervals, counting overlaps once,
+// so superseded pipelines that ran at the same time don't add up
+func unionDuration(intervals []interval, window interval) time.Duration {
+	clipped := []interval{}
+	for _, i := range intervals {
+		if i.start.Before(window.start) {
+			i.start = window.start
+		}
+		if i.end.After(window.end) {
+			i.end = window.end
+		}
+		if i.end.After(i.start) {
+			clipped = append(clipped, i)
+		}
+	}
+	sort.Slice(clipped, func(i, j int) bool { return clipped[i].start.Before(clipped[j].start) })
+
+	var total time.Duration
+	var current interval
+	for i, next := range clipped {
+		switch {
+		case i == 0:
+			current = next
+		case next.start.After(current.end):
+			total += current.end.Sub(current.start)
+			current = next
+		case next.end.After(current.end):
+			current.end = next.end
+		}
+	}
+	if len(clipped) > 0 {
+		total += current.end.Sub(current.start)
+	}
+	return total
+}
+
+// analyzeJobs returns how many jobs of a pipeline were retried and the names of those that failed before passing
+func analyzeJobs(jobs []Job) (int, []string) {
+	// GitLab returns the newest jobs first, the attempts are analyzed in the order they ran
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	attempts := map[string]int{}
+	failed := map[string]bool{}
+	flakyJobs := []string{}
+	retries := 0
+
+	for _, job := range jobs {
+		attempts[job.Name]++
+		if attempts[job.Name] > 1 {
+			retries++
+		}
+
+		switch job.Status {
+		case JobStatusFailed:
+			failed[job.Name] = true
+		case JobStatusSuccess:
+			if failed[job.Name] {
+				flakyJobs = appendUnique(flakyJobs, job.Name)
+			}
+		}
+	}
+
+	return retries, flakyJobs
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, item := range list {
+			if item == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,45 @@
+package mergerequests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalyzeJobs(t *testing.T) {
+	// GitLab returns the newest jobs first
+	jobs := []Job{
+		{ID: 6, Name: "e2e", Status: JobStatusSuccess},
+		{ID: 5, Name: "e2e", Status: JobStatusFailed},
+		{ID: 4, Name: "lint", Status: JobStatusSuccess},
+		{ID: 3, Name: "lint", Status: JobStatusSuccess},
+		{ID: 2, Name: "e2e", Status: JobStatusFailed},
+		{ID: 1, Name: "build", Status: JobStatusSuccess},
+	}
+
+	retries, flakyJobs := analyzeJobs(jobs)
+
+	if retries != 3 {
+		t.Errorf("Reintentos incorrectos, se esperaba 3 pero se obtuvo %d", retries)
+	}
+	if len(flakyJobs) != 1 || flakyJobs[0] != "e2e" {
+		t.Errorf("Jobs flaky incorrectos, se esperaba [e2e] pero se obtuvo %v", flakyJobs)
+	}
+}
+
+func TestUnionDuration(t *testing.T) {
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2023, 7, 3, hour, minute, 0, 0, time.UTC)
+	}
+
+	intervals := []interval{
+		{at(10, 0), at(10, 30)},
+		{at(10, 20), at(10, 50)}, // Superseded by a new push while it was running
+		{at(12, 0), at(12, 10)},
+		{at(8, 0), at(9, 10)}, // Starts before the window
+	}
+
+	got := unionDuration(intervals, interval{at(9, 0), at(13, 0)})
+	if got != 70*time.Minute {
+		t.Errorf("Tiempo de CI incorrecto, se esperaba %v pero se obtuvo %v", 70*time.Minute, got)
+	}
+}
@@ -0,0 +1,120 @@
+package mergerequests
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultExcludedPaths are the globs of lockfiles, vendored dependencies, generated code and migrations,
+// which don't reflect the effort of reviewing a merge request
+var DefaultExcludedPaths = []string{
+	"go.sum",
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"Gemfile.lock",
+	"composer.lock",
+	"poetry.lock",
+	"vendor/**",
+	"**/node_modules/**",
+	"*.min.js",
+	"*.min.css",
+	"*.pb.go",
+	"*.generated.*",
+	"*_generated.*",
+	"**/migrations/**",
+}
+
+// diffStats summarizes the changes of a merge request that count for its size
+type diffStats struct {
+	Additions int
+	Deletions int
+	Files     int
+	Truncated bool
+	// Counted files GitLab returned without their diff, their lines are unknown
+	MissingDiffs int
+}
+
+// calculateDiffStats counts the lines added and deleted in the changes whose path doesn't match any of the excluded globs.
+// The stats are flagged as truncated when GitLab didn't return the whole diff of a counted file, so the size is
+// only a lower bound, and the files without their diff are counted apart.
+func calculateDiffStats(change MergeRequestChange, excludedPaths []string) diffStats {
+	stats := diffStats{}
+
+	for _, change := range change.Changes {
+		if isExcluded(change.NewPath, excludedPaths) || (change.NewPath == "" && isExcluded(change.OldPath, excludedPaths)) {
+			continue
+		}
+
+		stats.Files++
+		if !hasDiff(change) {
+			stats.MissingDiffs++
+			stats.Truncated = true
+			continue
+		}
+
+		lines := strings.Split(change.Diff, "\n")
+
+		for _, line := range lines {
+			if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+				stats.Additions++
+			} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
+				stats.Deletions++
+			}
+		}
+	}
+
+	return stats
+}
+
+// hasDiff reports whether GitLab returned the whole diff of the file. The diff is empty without being truncated
+// when the file was only renamed, its mode changed or it is an empty file created or deleted.
+func hasDiff(change Change) bool {
+	if change.TooLarge || change.Collapsed {
+		return false
+	}
+	return change.Diff != "" || change.RenamedFile || change.NewFile || change.DeletedFile || change.AMode != change.BMode
+}
+
+// isExcluded reports whether the file path matches any of the globs
+func isExcluded(filePath string, globs []string) bool {
+	for _, glob := range globs {
+		if matchGlob(glob, filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a file path against a glob. Globs without a "/" are matched against the file name,
+// otherwise against the whole path, where "**" matches any number of directories.
+func matchGlob(glob string, filePath string) bool {
+	if !strings.Contains(glob, "/") {
+		matched, _ := path.Match(glob, path.Base(filePath))
+		return matched
+	}
+
+	return matchSegments(strings.Split(glob, "/"), strings.Split(filePath, "/"))
+}
+
+func matchSegments(glob []string, segments []string) bool {
+	if len(glob) == 0 {
+		return len(segments) == 0
+	}
+
+	if glob[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	matched, _ := path.Match(glob[0], segments[0])
+	return matched && matchSegments(glob[1:], segments[1:])
+}
@@ -0,0 +1,55 @@
+package mergerequests
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		glob     string
+		path     string
+		expected bool
+	}{
+		{"go.sum", "go.sum", true},
+		{"go.sum", "tools/go.sum", true},
+		{"*.min.js", "static/js/app.min.js", true},
+		{"vendor/**", "vendor/github.com/pkg/errors/errors.go", true},
+		{"vendor/**", "pkg/vendor/file.go", false},
+		{"**/migrations/**", "db/migrations/0001_init.sql", true},
+		{"**/migrations/**", "migrations/0001_init.sql", true},
+		{"**/migrations/**", "pkg/migrate.go", false},
+	}
+
+	for _, c := range cases {
+		if got := matchGlob(c.glob, c.path); got != c.expected {
+			t.Errorf("matchGlob(%q, %q) incorrecto, se esperaba %t pero se obtuvo %t", c.glob, c.path, c.expected, got)
+		}
+	}
+}
+
+func TestCalculateDiffStats(t *testing.T) {
+	change := MergeRequestChange{
+		Changes: []Change{
+			{NewPath: "pkg/api/api.go", Diff: "--- a/pkg/api/api.go\n+++ b/pkg/api/api.go\n@@ -1,2 +1,3 @@\n package api\n-old\n+new\n+another\n"},
+			{NewPath: "go.sum", Diff: "+github.com/a/b v1.0.0\n+github.com/a/b v1.0.0/go.mod\n"},
+			{NewPath: "db/migrations/0002.sql", Diff: "+ALTER TABLE x;\n"},
+			{NewPath: "static/big.json", TooLarge: true},
+			{NewPath: "pkg/api/handlers.go", Collapsed: true},
+			{NewPath: "pkg/api/static.go", Diff: ""},
+			{OldPath: "pkg/api/old.go", NewPath: "pkg/api/new.go", RenamedFile: true},
+		},
+	}
+
+	stats := calculateDiffStats(change, DefaultExcludedPaths)
+
+	if stats.Additions != 2 || stats.Deletions != 1 {
+		t.Errorf("Tamaño incorrecto, se esperaba +2/-1 pero se obtuvo +%d/-%d", stats.Additions, stats.Deletions)
+	}
+	if stats.Files != 5 {
+		t.Errorf("Archivos incorrectos, se esperaba 5 pero se obtuvo %d", stats.Files)
+	}
+	if stats.MissingDiffs != 3 {
+		t.Errorf("Archivos sin diff incorrectos, se esperaba 3 pero se obtuvo %d", stats.MissingDiffs)
+	}
+	if !stats.Truncated {
+		t.Errorf("Se esperaba que el diff esté marcado como truncado")
+	}
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import "math"
+
+// Distribution describes the shape of a set of values
+type Distribution struct {
+	Mean     float64 `json:"mean"`
+	StdDev   float64 `json:"std_dev"`  // Population standard deviation
+	Skewness float64 `json:"skewness"` // Positive when a tail of high values pulls the mean above most of the values
+}
+
+// NewDistribution returns the mean, the standard deviation and the skewness of the values.
+// The skewness is the Fisher-Pearson coefficient, 0 if every value is the same.
+func NewDistribution(values []int) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	var sum float64
+	for _, value := range values {
+		sum += float64(value)
+	}
+	mean := sum / float64(len(values))
+
+	var m2, m3 float64
+	for _, value := range values {
+		diff := float64(value) - mean
+		m2 += diff * diff
+		m3 += diff * diff * diff
+	}
+	m2 /= float64(len(values))
+	m3 /= float64(len(values))
+
+	distribution := Distribution{Mean: mean, StdDev: math.Sqrt(m2)}
+	if m2 > 0 {
+		distribution.Skewness = m3 / math.Pow(m2, 1.5)
+	}
+	return distribution
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
@@ -31,6 +32,7 @@ type Status struct {
 
 type Workflow struct {
 	Statuses map[string]Status
+	Holidays []time.Time // Days not counted in the times of the tasks, at midnight
 }
 
 type Transition struct {
@@ -79,19 +81,26 @@ func minutesToDays(minutes int) int {
 
 // calculateMetrics calculates the overall metrics based on the time spent in each state
 func CalculateMetrics() []MetricsPerTask {
+	return Calculate(wf, taskInfo)
+}
+
+// Calculate calculates the metrics of the tasks for the given workflow.
+// Unlike CalculateMetrics it doesn't depend on the package state, so it can be called concurrently.
+func Calculate(wf Workflow, tasks []TaskInfo) []MetricsPerTask {
 	metricsPerTask := []MetricsPerTask{}
 
-	for _, ti := range taskInfo {
+	for _, ti := range tasks {
 		metrics := MetricsPerTask{
 			TaskInfo: ti,
 		}
 		for _, entry := range ti.History {
-			metrics.Metrics.LeadTime += minutesToDays(entry.Time)
+			minutes := wf.workingMinutes(entry)
+			metrics.Metrics.LeadTime += minutesToDays(minutes)
 			if wf.Statuses[entry.Status].IsCycleTimeCalculable {
-				metrics.Metrics.CycleTime += minutesToDays(entry.Time)
+				metrics.Metrics.CycleTime += minutesToDays(minutes)
 			}
 			if wf.Statuses[entry.Status].Blocked || wf.Statuses[entry.Status].Pending {
-				metrics.Metrics.BlockedTime += minutesToDays(entry.Time)
+				metrics.Metrics.BlockedTime += minutesToDays(minutes)
 			}
 		}
 
@@ -109,3 +118,60 @@ func CalculateMetrics() []MetricsPerTask {
 
 	return metricsPerTask
 }
+
+// workingMinutes returns the minutes the task spent in the status of the entry, without the holidays of the workflow
+func (wf Workflow) workingMinutes(entry data.History) int {
+	if len(wf.Holidays) == 0 {
+		return entry.Time
+	}
+	millis, err := strconv.ParseInt(entry.Since, 10, 64)
+	if err != nil {
+		return entry.Time
+	}
+
+	start := time.UnixMilli(millis)
+	end := start.Add(time.Duration(entry.Time) * time.Minute)
+	minutes := entry.Time
+	for _, holiday := range wf.Holidays {
+		from, to := holiday, holiday.AddDate(0, 0, 1)
+		if start.After(from) {
+			from = start
+		}
+		if end.Before(to) {
+			to = end
+		}
+		if to.After(from) {
+			minutes -= int(to.Sub(from).Minutes())
+		}
+	}
+	return minutes
+}
+
+// DoneAt returns when the task with the status and the history was done: when it entered the last of its done
+// statuses. Tasks whose status is not done are not done, even if they were done before.
+func (wf Workflow) DoneAt(status string, history []data.History) (time.Time, bool) {
+	if !wf.Statuses[status].Done {
+		return time.Time{}, false
+	}
+
+	var doneAt time.Time
+	for _, entry := range history {
+		if !wf.Statuses[entry.Status].Done {
+			continue
+		}
+		millis, err := strconv.ParseInt(entry.Since, 10, 64)
+		if err != nil {
+			continue
+		}
+		if since := time.UnixMilli(millis); since.After(doneAt) {
+			doneAt = since
+		}
+	}
+	return doneAt, !doneAt.IsZero()
+}
+
+// IsWorkInProgress returns whether a task with the status is started and not done
+func (wf Workflow) IsWorkInProgress(status string) bool {
+	st := wf.Statuses[status]
+	return !st.Done && (st.InProgress || st.IsCycleTimeCalculable || st.Blocked)
+}
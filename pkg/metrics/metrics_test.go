@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+)
+
+func TestCalculateWithHolidays(t *testing.T) {
+	monday := time.Date(2024, 12, 23, 9, 0, 0, 0, time.Local)
+	wf := Workflow{Statuses: map[string]Status{
+		"in development": {Name: "in development", IsCycleTimeCalculable: true},
+	}}
+	task := TaskInfo{Id: "1", History: []data.History{
+		// From monday to friday
+		{Status: "in development", Time: 4 * 24 * 60, Since: strconv.FormatInt(monday.UnixMilli(), 10)},
+	}}
+
+	result := Calculate(wf, []TaskInfo{task})
+	if result[0].Metrics.CycleTime != 4 {
+		t.Errorf("Cycle time incorrecto, se esperaba %d pero se obtuvo %d", 4, result[0].Metrics.CycleTime)
+	}
+
+	wf.Holidays = []time.Time{time.Date(2024, 12, 25, 0, 0, 0, 0, time.Local)}
+	result = Calculate(wf, []TaskInfo{task})
+	if result[0].Metrics.CycleTime != 3 {
+		t.Errorf("Cycle time sin feriados incorrecto, se esperaba %d pero se obtuvo %d", 3, result[0].Metrics.CycleTime)
+	}
+	if result[0].Metrics.LeadTime != 3 {
+		t.Errorf("Lead time sin feriados incorrecto, se esperaba %d pero se obtuvo %d", 3, result[0].Metrics.LeadTime)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []int{5, 1, 4, 2, 3, 10, 7, 6, 9, 8}
+	tests := map[float64]int{50: 5, 85: 9, 95: 10, 0: 1}
+	for p, expected := range tests {
+		if got := Percentile(values, p); got != expected {
+			t.Errorf("Percentil %.0f incorrecto, se esperaba %d pero se obtuvo %d", p, expected, got)
+		}
+	}
+	if Percentile(nil, 85) != 0 {
+		t.Errorf("El percentil sin valores debería ser 0")
+	}
+}
+
+func TestNewDistribution(t *testing.T) {
+	distribution := NewDistribution([]int{2, 4, 4, 4, 5, 5, 7, 9})
+	if distribution.Mean != 5 || distribution.StdDev != 2 || distribution.Skewness != 0.65625 {
+		t.Errorf("Distribución incorrecta, se obtuvo %+v", distribution)
+	}
+	if NewDistribution([]int{3, 3}).Skewness != 0 {
+		t.Errorf("La asimetría de valores iguales debería ser 0")
+	}
+}
+
+func TestDoneAt(t *testing.T) {
+	wf := Workflow{Statuses: map[string]Status{
+		"in development": {Name: "in development", InProgress: true},
+		"completed":      {Name: "completed", Done: true},
+	}}
+	history := []data.History{
+		{Status: "in development", Time: 60, Since: "1700000000000"},
+		{Status: "completed", Time: 0, Since: "1700003600000"},
+	}
+
+	doneAt, done := wf.DoneAt("completed", history)
+	if !done || doneAt.UnixMilli() != 1700003600000 {
+		t.Errorf("Fecha de finalización incorrecta, se obtuvo %v", doneAt)
+	}
+	if _, done := wf.DoneAt("in development", history); done {
+		t.Errorf("Una tarea reabierta no debería estar terminada")
+	}
+	if !wf.IsWorkInProgress("in development") || wf.IsWorkInProgress("completed") {
+		t.Errorf("Trabajo en progreso incorrecto")
+	}
+}
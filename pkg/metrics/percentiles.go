@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Percentile returns the nearest-rank percentile p (between 0 and 100) of the values, or 0 if there are none
+func Percentile(values []int, p float64) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
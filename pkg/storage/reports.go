@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	slugLength   = 8
+	slugAlphabet = "abcdefghijkmnpqrstuvwxyz23456789" // Without the characters that look alike
+	slugAttempts = 5
+)
+
+// SavedReport is the definition of a dashboard report, saved to be opened again with its slug
+type SavedReport struct {
+	Slug      string
+	Name      string
+	Tickets   string
+	StartDate string
+	EndDate   string
+	Prefix    string
+	DateField string
+	Projects  string
+	Source    string
+	Team      string // Team whose configuration fills the empty filters, if any
+	Period    string // Periods the dates are split into, if any
+	CreatedAt time.Time
+}
+
+// SaveReport saves the definition of the report with a new random slug and returns it
+func (s *Store) SaveReport(report SavedReport) (string, error) {
+	createdAt := report.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	for attempt := 0; attempt < slugAttempts; attempt++ {
+		slug, err := newSlug()
+		if err != nil {
+			return "", err
+		}
+
+		res, err := s.db.Exec(`INSERT OR IGNORE INTO saved_reports (slug, name, tickets, start_date, end_date, prefix, date_field, projects, source, team, period, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			slug, report.Name, report.Tickets, report.StartDate, report.EndDate, report.Prefix, report.DateField, report.Projects, report.Source,
+			report.Team, report.Period, createdAt.UTC().Format(time.RFC3339))
+		if err != nil {
+			return "", err
+		}
+		// Nothing is inserted when the slug is taken, another one is tried
+		if inserted, err := res.RowsAffected(); err != nil || inserted == 1 {
+			return slug, err
+		}
+	}
+	return "", fmt.Errorf("error saving report %s: no free slug after %d attempts", report.Name, slugAttempts)
+}
+
+// GetReport returns the saved report with the slug, or ErrReportNotFound
+func (s *Store) GetReport(slug string) (SavedReport, error) {
+	row := s.db.QueryRow(`SELECT slug, name, tickets, start_date, end_date, prefix, date_field, projects, source, team, period, created_at
+		FROM saved_reports WHERE slug = ?`, strings.ToLower(slug))
+	report, err := scanReport(row)
+	if err == sql.ErrNoRows {
+		return report, fmt.Errorf("%w: %s", ErrReportNotFound, slug)
+	}
+	return report, err
+}
+
+// GetReports returns the saved reports of the team, or every saved report if team is empty, most recent first
+func (s *Store) GetReports(team string) ([]SavedReport, error) {
+	rows, err := s.db.Query(`SELECT slug, name, tickets, start_date, end_date, prefix, date_field, projects, source, team, period, created_at
+		FROM saved_reports WHERE ? = '' OR team = ? ORDER BY created_at DESC, name`, team, team)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []SavedReport{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanReport reads a saved report from a row of saved_reports, selected with all its columns in order
+func scanReport(row rowScanner) (SavedReport, error) {
+	var report SavedReport
+	var createdAt string
+	err := row.Scan(&report.Slug, &report.Name, &report.Tickets, &report.StartDate, &report.EndDate, &report.Prefix,
+		&report.DateField, &report.Projects, &report.Source, &report.Team, &report.Period, &createdAt)
+	if err != nil {
+		return report, err
+	}
+	report.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return report, nil
+}
+
+// newSlug returns a random slug, short enough to be typed
+func newSlug() (string, error) {
+	slug := make([]byte, slugLength)
+	max := big.NewInt(int64(len(slugAlphabet)))
+	for i := range slug {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		slug[i] = slugAlphabet[n.Int64()]
+	}
+	return string(slug), nil
+}
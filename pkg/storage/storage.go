@@ -0,0 +1,443 @@
+/*
+Package storage persists snapshots of the ClickUp tasks and the GitLab merge requests in a local SQLite database,
+so reports can be compared over time even after the data changes in ClickUp. The metrics are computed from the
+stored histories when they are read.
+
+Every ingestion creates a new snapshot. Reads return the most recent copy of each task or merge request,
+or the most recent one as of a snapshot with AsOf.
+
+Usage:
+
+	store, err := storage.Open("metrics.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	snapshotID, err := store.SaveSnapshot(storage.Snapshot{Tasks: tasks, MergeRequests: mrs})
+*/
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+
+	// SQLite driver written in Go, so the service still builds without cgo
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS snapshots (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	taken_at    TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+	snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+	id          TEXT NOT NULL,
+	custom_id   TEXT NOT NULL DEFAULT '',
+	name        TEXT NOT NULL DEFAULT '',
+	start_date  TEXT NOT NULL DEFAULT '',
+	due_date    TEXT NOT NULL DEFAULT '',
+	status      TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (snapshot_id, id)
+);
+CREATE INDEX IF NOT EXISTS tasks_custom_id ON tasks (custom_id);
+
+CREATE TABLE IF NOT EXISTS task_history (
+	snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+	task_id     TEXT NOT NULL,
+	position    INTEGER NOT NULL,
+	status      TEXT NOT NULL,
+	minutes     INTEGER NOT NULL,
+	since       TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (snapshot_id, task_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS merge_requests (
+	snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+	project_id  INTEGER NOT NULL,
+	iid         INTEGER NOT NULL,
+	title       TEXT NOT NULL,
+	created_at  TEXT NOT NULL,
+	merged_at   TEXT NOT NULL,
+	data        TEXT NOT NULL,
+	PRIMARY KEY (snapshot_id, project_id, iid)
+);
+CREATE INDEX IF NOT EXISTS merge_requests_merged_at ON merge_requests (merged_at);
+`
+
+// migrations change the schema of the databases created by previous versions, in order.
+// The number of applied migrations is kept in the user_version of the database.
+var migrations = []string{
+	`ALTER TABLE tasks ADD COLUMN list_id TEXT NOT NULL DEFAULT ''`,
+	// The metrics are computed from the stored histories, with the current workflow
+	`DROP TABLE IF EXISTS task_metrics`,
+	`CREATE TABLE IF NOT EXISTS saved_reports (
+		slug       TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		tickets    TEXT NOT NULL DEFAULT '',
+		start_date TEXT NOT NULL DEFAULT '',
+		end_date   TEXT NOT NULL DEFAULT '',
+		prefix     TEXT NOT NULL DEFAULT '',
+		date_field TEXT NOT NULL DEFAULT '',
+		projects   TEXT NOT NULL DEFAULT '',
+		source     TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	)`,
+	`ALTER TABLE saved_reports ADD COLUMN team TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE saved_reports ADD COLUMN period TEXT NOT NULL DEFAULT ''`,
+}
+
+// Errors returned when the requested snapshot or saved report doesn't exist
+var (
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+	ErrReportNotFound   = errors.New("saved report not found")
+)
+
+// Snapshot is the data saved by an ingestion
+type Snapshot struct {
+	ID            int64
+	TakenAt       time.Time
+	Description   string
+	Tasks         []data.TaskInfo
+	MergeRequests []mergerequests.MergeRequest
+}
+
+// Store is a SQLite database of snapshots. It implements data.Data, so the metrics can be computed from the stored tasks.
+type Store struct {
+	db *sql.DB
+	// snapshotID is the most recent snapshot the reads see, all of them if 0
+	snapshotID int64
+}
+
+// Open opens the SQLite database at path, creating it and its tables if they don't exist
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite supports a single writer, concurrent requests wait for the connection instead of failing
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating the schema: %v", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// migrate applies the migrations the database doesn't have yet
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if _, err := db.Exec(migrations[i]); err != nil {
+			return fmt.Errorf("error applying migration %d: %v", i+1, err)
+		}
+		if _, err := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// AsOf returns a view of the store whose reads ignore the snapshots taken after snapshotID.
+// It shares the database of s, which must stay open while the view is used.
+func (s *Store) AsOf(snapshotID int64) *Store {
+	return &Store{db: s.db, snapshotID: snapshotID}
+}
+
+// lastSnapshot is the most recent snapshot the reads see
+func (s *Store) lastSnapshot() int64 {
+	if s.snapshotID == 0 {
+		return math.MaxInt64
+	}
+	return s.snapshotID
+}
+
+// SaveSnapshot saves the tasks, metrics and merge requests as a new snapshot and returns its ID
+func (s *Store) SaveSnapshot(snapshot Snapshot) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	takenAt := snapshot.TakenAt
+	if takenAt.IsZero() {
+		takenAt = time.Now()
+	}
+
+	res, err := tx.Exec(`INSERT INTO snapshots (taken_at, description) VALUES (?, ?)`, takenAt.UTC().Format(time.RFC3339), snapshot.Description)
+	if err != nil {
+		return 0, err
+	}
+	snapshotID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, task := range snapshot.Tasks {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO tasks (snapshot_id, id, custom_id, name, start_date, due_date, status, list_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			snapshotID, task.Id, task.CustomId, task.Name, task.StartDate, task.DueDate, task.Status, task.ListID)
+		if err != nil {
+			return 0, fmt.Errorf("error saving task %s: %v", task.Id, err)
+		}
+
+		for position, history := range task.History {
+			_, err := tx.Exec(`INSERT OR REPLACE INTO task_history (snapshot_id, task_id, position, status, minutes, since) VALUES (?, ?, ?, ?, ?, ?)`,
+				snapshotID, task.Id, position, history.Status, history.Time, history.Since)
+			if err != nil {
+				return 0, fmt.Errorf("error saving history of task %s: %v", task.Id, err)
+			}
+		}
+	}
+
+	for _, mr := range snapshot.MergeRequests {
+		mrData, err := json.Marshal(mr)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(`INSERT OR REPLACE INTO merge_requests (snapshot_id, project_id, iid, title, created_at, merged_at, data) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			snapshotID, mr.ProjectID, mr.IID, mr.Title, mr.CreatedAt, mr.MergedAt, string(mrData))
+		if err != nil {
+			return 0, fmt.Errorf("error saving merge request %d of project %d: %v", mr.IID, mr.ProjectID, err)
+		}
+	}
+
+	return snapshotID, tx.Commit()
+}
+
+// GetTasksWithFilter returns the most recent copy of the tasks of the list of the filter, or of every task if it has
+// no list. The store doesn't keep when the tasks were updated, so the dates of the filter are ignored.
+func (s *Store) GetTasksWithFilter(filter data.Filter) ([]data.TaskHeaderData, error) {
+	rows, err := s.db.Query(`SELECT t.id, t.custom_id, t.name, t.start_date, t.due_date, t.status, t.list_id FROM tasks t
+		JOIN (SELECT id, MAX(snapshot_id) AS snapshot_id FROM tasks WHERE snapshot_id <= ? GROUP BY id) latest
+		ON t.id = latest.id AND t.snapshot_id = latest.snapshot_id
+		WHERE ? = '' OR t.list_id = ?
+		ORDER BY t.id`, s.lastSnapshot(), filter.ListID, filter.ListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []data.TaskHeaderData{}
+	for rows.Next() {
+		task := data.TaskHeaderData{}
+		if err := rows.Scan(&task.Id, &task.CustomId, &task.Name, &task.StartDate, &task.DueDate, &task.Status, &task.ListID); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetTaskByID returns the most recent copy of the task, looking it up by its ID or custom ID
+func (s *Store) GetTaskByID(id string) (*data.TaskInfo, error) {
+	var snapshotID int64
+	task := data.TaskInfo{}
+
+	row := s.db.QueryRow(`SELECT snapshot_id, id, custom_id, name, start_date, due_date, status, list_id FROM tasks
+		WHERE (id = ? OR custom_id = ?) AND snapshot_id <= ? ORDER BY snapshot_id DESC LIMIT 1`, id, id, s.lastSnapshot())
+	err := row.Scan(&snapshotID, &task.Id, &task.CustomId, &task.Name, &task.StartDate, &task.DueDate, &task.Status, &task.ListID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", data.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	task.History, err = s.getHistory(snapshotID, task.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetLatestTasks returns the most recent copy of every stored task
+func (s *Store) GetLatestTasks() ([]data.TaskInfo, error) {
+	rows, err := s.db.Query(`SELECT t.snapshot_id, t.id, t.custom_id, t.name, t.start_date, t.due_date, t.status, t.list_id FROM tasks t
+		JOIN (SELECT id, MAX(snapshot_id) AS snapshot_id FROM tasks WHERE snapshot_id <= ? GROUP BY id) latest
+		ON t.id = latest.id AND t.snapshot_id = latest.snapshot_id
+		ORDER BY t.id`, s.lastSnapshot())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotIDs := []int64{}
+	tasks := []data.TaskInfo{}
+	for rows.Next() {
+		var snapshotID int64
+		task := data.TaskInfo{}
+		if err := rows.Scan(&snapshotID, &task.Id, &task.CustomId, &task.Name, &task.StartDate, &task.DueDate, &task.Status, &task.ListID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snapshotIDs = append(snapshotIDs, snapshotID)
+		tasks = append(tasks, task)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The histories are read once the tasks are, the store has a single connection
+	for i := range tasks {
+		tasks[i].History, err = s.getHistory(snapshotIDs[i], tasks[i].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tasks, nil
+}
+
+// getHistory returns the history of the task saved in the snapshot
+func (s *Store) getHistory(snapshotID int64, taskID string) ([]data.History, error) {
+	rows, err := s.db.Query(`SELECT status, minutes, since FROM task_history WHERE snapshot_id = ? AND task_id = ? ORDER BY position`, snapshotID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []data.History{}
+	for rows.Next() {
+		var entry data.History
+		if err := rows.Scan(&entry.Status, &entry.Time, &entry.Since); err != nil {
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+	return history, rows.Err()
+}
+
+// GetHistoryPerTask is not supported by the store
+func (s *Store) GetHistoryPerTask(ids []string) *map[string]data.TaskInfo {
+	return nil
+}
+
+// GetWorkflow returns nil, the store doesn't own a workflow and the metrics are computed with the one of ClickUp
+func (s *Store) GetWorkflow() *data.Workflow {
+	return nil
+}
+
+// GetMergeRequestsBetween returns the most recent copy of the merge requests whose title contains prefix and whose
+// creation or merge date is between startDate and endDate (YYYY-MM-DD). The update date is not stored,
+// so DateFieldUpdated is handled as DateFieldMerged.
+func (s *Store) GetMergeRequestsBetween(prefix string, startDate string, endDate string, dateField mergerequests.DateField) ([]mergerequests.MergeRequest, error) {
+	column := "merged_at"
+	if dateField == mergerequests.DateFieldCreated {
+		column = "created_at"
+	}
+
+	// Dates are stored as "YYYY-MM-DD HH:MM", so they can be compared as strings
+	rows, err := s.db.Query(`SELECT mr.data FROM merge_requests mr
+		JOIN (SELECT project_id, iid, MAX(snapshot_id) AS snapshot_id FROM merge_requests WHERE snapshot_id <= ? GROUP BY project_id, iid) latest
+		ON mr.project_id = latest.project_id AND mr.iid = latest.iid AND mr.snapshot_id = latest.snapshot_id
+		WHERE mr.`+column+` >= ? AND mr.`+column+` <= ? AND mr.title LIKE ? ESCAPE '\'
+		ORDER BY mr.created_at`,
+		s.lastSnapshot(), startDate, endDate+" 23:59", "%"+likeEscaper.Replace(prefix)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []mergerequests.MergeRequest{}
+	for rows.Next() {
+		var mrData string
+		if err := rows.Scan(&mrData); err != nil {
+			return nil, err
+		}
+		var mr mergerequests.MergeRequest
+		if err := json.Unmarshal([]byte(mrData), &mr); err != nil {
+			return nil, err
+		}
+		result = append(result, mr)
+	}
+
+	return result, rows.Err()
+}
+
+// likeEscaper escapes the wildcards of LIKE, so the prefix is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// GetLatestMergeRequests returns the most recent copy of every stored merge request
+func (s *Store) GetLatestMergeRequests() ([]mergerequests.MergeRequest, error) {
+	return s.GetMergeRequestsBetween("", "", "9999-12-31", mergerequests.DateFieldMerged)
+}
+
+// MergeRequests returns a source of the stored merge requests whose title contains prefix,
+// with the same method the GitLab client uses to retrieve them
+func (s *Store) MergeRequests(prefix string) *MergeRequestSource {
+	return &MergeRequestSource{store: s, prefix: prefix}
+}
+
+// MergeRequestSource retrieves the stored merge requests whose title contains a prefix
+type MergeRequestSource struct {
+	store  *Store
+	prefix string
+}
+
+func (m *MergeRequestSource) GetMergeRequestsBetween(startDate string, endDate string, dateField mergerequests.DateField) ([]mergerequests.MergeRequest, error) {
+	return m.store.GetMergeRequestsBetween(m.prefix, startDate, endDate, dateField)
+}
+
+// GetSnapshot returns the snapshot without its data, or ErrSnapshotNotFound
+func (s *Store) GetSnapshot(id int64) (Snapshot, error) {
+	snapshot := Snapshot{ID: id}
+	var takenAt string
+	err := s.db.QueryRow(`SELECT taken_at, description FROM snapshots WHERE id = ?`, id).Scan(&takenAt, &snapshot.Description)
+	if err == sql.ErrNoRows {
+		return snapshot, fmt.Errorf("%w: %d", ErrSnapshotNotFound, id)
+	}
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.TakenAt, _ = time.Parse(time.RFC3339, takenAt)
+	return snapshot, nil
+}
+
+// GetSnapshots returns the snapshots without their data, most recent first
+func (s *Store) GetSnapshots() ([]Snapshot, error) {
+	rows, err := s.db.Query(`SELECT id, taken_at, description FROM snapshots WHERE id <= ? ORDER BY id DESC`, s.lastSnapshot())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []Snapshot{}
+	for rows.Next() {
+		var snapshot Snapshot
+		var takenAt string
+		if err := rows.Scan(&snapshot.ID, &takenAt, &snapshot.Description); err != nil {
+			return nil, err
+		}
+		snapshot.TakenAt, _ = time.Parse(time.RFC3339, takenAt)
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
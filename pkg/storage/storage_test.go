@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/data"
+	"github.com/lucasvillalbaar/clickup-metrics/pkg/mergerequests"
+)
+
+func openTestStore(t *testing.T) *Store {
+	store, err := Open(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func newTask(status string, minutes int) data.TaskInfo {
+	return data.TaskInfo{
+		TaskHeaderData: data.TaskHeaderData{Id: "86a0x1", CustomId: "PRJ-1", Name: "Task", Status: status},
+		History: []data.History{
+			{Status: "in development", Time: minutes, Since: "1700000000000"},
+			{Status: "completed", Time: 0, Since: "1700003600000"},
+		},
+	}
+}
+
+func TestGetTaskByIDReturnsLatestSnapshot(t *testing.T) {
+	store := openTestStore(t)
+
+	for _, task := range []data.TaskInfo{newTask("in development", 30), newTask("completed", 60)} {
+		_, err := store.SaveSnapshot(Snapshot{Tasks: []data.TaskInfo{task}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	task, err := store.GetTaskByID("PRJ-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != "completed" {
+		t.Errorf("Estado incorrecto, se esperaba %s pero se obtuvo %s", "completed", task.Status)
+	}
+	if len(task.History) != 2 || task.History[0].Time != 60 {
+		t.Errorf("Historial incorrecto, se obtuvo %+v", task.History)
+	}
+
+	_, err = store.GetTaskByID("PRJ-2")
+	if !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("Se esperaba ErrNotFound pero se obtuvo %v", err)
+	}
+
+	snapshots, err := store.GetSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("Cantidad de snapshots incorrecta, se esperaba %d pero se obtuvo %d", 2, len(snapshots))
+	}
+
+	task, err = store.AsOf(snapshots[1].ID).GetTaskByID("PRJ-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != "in development" {
+		t.Errorf("Estado incorrecto, se esperaba %s pero se obtuvo %s", "in development", task.Status)
+	}
+
+	_, err = store.GetSnapshot(snapshots[0].ID + 1)
+	if !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("Se esperaba ErrSnapshotNotFound pero se obtuvo %v", err)
+	}
+}
+
+func TestGetTasksWithFilter(t *testing.T) {
+	store := openTestStore(t)
+
+	other := newTask("completed", 60)
+	other.Id, other.CustomId, other.ListID = "86a0x2", "PRJ-2", "902"
+	task := newTask("in development", 30)
+	task.ListID = "901"
+	if _, err := store.SaveSnapshot(Snapshot{Tasks: []data.TaskInfo{task, other}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := store.GetTasksWithFilter(data.Filter{ListID: "901"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].CustomId != "PRJ-1" {
+		t.Errorf("Tareas de la lista incorrectas, se obtuvo %+v", tasks)
+	}
+
+	tasks, err = store.GetTasksWithFilter(data.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Cantidad de tareas incorrecta, se esperaba %d pero se obtuvo %d", 2, len(tasks))
+	}
+}
+
+func TestGetMergeRequestsBetween(t *testing.T) {
+	store := openTestStore(t)
+
+	_, err := store.SaveSnapshot(Snapshot{MergeRequests: []mergerequests.MergeRequest{
+		{ProjectID: 1, IID: 1, Title: "PRJ-1 first", CreatedAt: "2024-01-30 10:00", MergedAt: "2024-02-01 10:00"},
+		{ProjectID: 1, IID: 2, Title: "PRJ-2 second", CreatedAt: "2024-02-10 10:00", MergedAt: "2024-02-29 18:00"},
+		{ProjectID: 1, IID: 3, Title: "OTHER-3 third", CreatedAt: "2024-02-10 10:00", MergedAt: "2024-02-11 10:00"},
+		{ProjectID: 1, IID: 4, Title: "PRJ-4 fourth", CreatedAt: "2024-02-20 10:00", MergedAt: "2024-03-01 10:00"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mrs, err := store.MergeRequests("PRJ").GetMergeRequestsBetween("2024-02-01", "2024-02-29", mergerequests.DateFieldMerged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mrs) != 2 || mrs[0].IID != 1 || mrs[1].IID != 2 {
+		t.Errorf("Merge requests incorrectos, se obtuvo %+v", mrs)
+	}
+
+	mrs, err = store.GetMergeRequestsBetween("PRJ", "2024-02-01", "2024-02-29", mergerequests.DateFieldCreated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mrs) != 2 || mrs[0].IID != 2 || mrs[1].IID != 4 {
+		t.Errorf("Merge requests incorrectos, se obtuvo %+v", mrs)
+	}
+
+	// The wildcards of the prefix are matched literally
+	mrs, err = store.GetMergeRequestsBetween("J_1", "2024-01-01", "2024-03-31", mergerequests.DateFieldMerged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mrs) != 0 {
+		t.Errorf("Merge requests incorrectos, se obtuvo %+v", mrs)
+	}
+}
+
+func TestSaveReport(t *testing.T) {
+	store := openTestStore(t)
+
+	slug, err := store.SaveReport(SavedReport{Name: "Sprint 12", Tickets: "PRJ-1,PRJ-2", StartDate: "2024-01-01", EndDate: "2024-01-14", Prefix: "PRJ"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slug) != slugLength {
+		t.Errorf("Slug incorrecto: %s", slug)
+	}
+
+	report, err := store.GetReport(slug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Name != "Sprint 12" || report.Tickets != "PRJ-1,PRJ-2" || report.Prefix != "PRJ" || report.CreatedAt.IsZero() {
+		t.Errorf("Reporte incorrecto, se obtuvo %+v", report)
+	}
+
+	if _, err := store.SaveReport(SavedReport{Name: "Sprint 13", Team: "core"}); err != nil {
+		t.Fatal(err)
+	}
+	reports, err := store.GetReports("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("Cantidad de reportes incorrecta, se esperaba %d pero se obtuvo %d", 2, len(reports))
+	}
+	reports, err = store.GetReports("core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Name != "Sprint 13" {
+		t.Errorf("Reportes del equipo incorrectos, se obtuvo %+v", reports)
+	}
+
+	_, err = store.GetReport("missing")
+	if !errors.Is(err, ErrReportNotFound) {
+		t.Errorf("Se esperaba ErrReportNotFound pero se obtuvo %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Package teams loads the configuration of the teams whose metrics are tracked: where their work lives in ClickUp
+and GitLab, the statuses of their workflow and their holidays.
+
+The teams are read from a JSON file with a list of teams:
+
+	[
+		{
+			"id": "core",
+			"name": "Core",
+			"clickup_lists": ["901100000001"],
+			"workflow": {"cycle_time_statuses": ["in development", "in review"], "blocked_statuses": ["blocked"]},
+			"gitlab_projects": [123, 456],
+			"prefix": "CORE",
+			"holidays": ["2024-12-25"],
+			"sprint_days": 14,
+			"sle": {"percentile": 85, "days": 10}
+		}
+	]
+*/
+package teams
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrTeamNotFound is returned when the requested team is not configured
+var ErrTeamNotFound = errors.New("team not found")
+
+// Team is a team whose metrics are tracked
+type Team struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Lists    []string `json:"clickup_lists"`   // ClickUp lists with the tasks of the team
+	Workflow Workflow `json:"workflow"`        // Statuses of the team, the default workflow if empty
+	Projects []int    `json:"gitlab_projects"` // GitLab projects of the DORA metrics
+	Prefix   string   `json:"prefix"`          // Text of the titles of the merge requests of the team
+	Holidays []string `json:"holidays"`        // Days not counted in the times of the tasks (YYYY-MM-DD)
+	// Length of the sprints the trends of the team are split into, 14 days if 0
+	SprintDays int  `json:"sprint_days,omitempty"`
+	SLE        *SLE `json:"sle,omitempty"` // Service level expectation of the tasks of the team, none if nil
+}
+
+// SLE is a service level expectation: Percentile percent of the tasks are done within Days of cycle time
+type SLE struct {
+	Percentile float64 `json:"percentile"`
+	Days       int     `json:"days"`
+}
+
+// Workflow is the workflow profile of a team, the ClickUp statuses that differ from the default workflow
+type Workflow struct {
+	CycleTimeStatuses []string `json:"cycle_time_statuses,omitempty"` // Statuses counted as cycle time
+	BlockedStatuses   []string `json:"blocked_statuses,omitempty"`    // Statuses counted as blocked time
+}
+
+// Registry has the configured teams, in the order of the file
+type Registry struct {
+	teams []Team
+}
+
+// Load reads the teams from the JSON file at path
+func Load(path string) (*Registry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Parse reads the teams from a JSON list and validates them
+func Parse(r io.Reader) (*Registry, error) {
+	var teams []Team
+	if err := json.NewDecoder(r).Decode(&teams); err != nil {
+		return nil, fmt.Errorf("error reading teams: %w", err)
+	}
+
+	ids := map[string]bool{}
+	for i := range teams {
+		team := &teams[i]
+		team.ID = strings.TrimSpace(team.ID)
+		if team.ID == "" || strings.Contains(team.ID, ",") {
+			return nil, fmt.Errorf("team %d: the id is required and can't have commas", i+1)
+		}
+		if ids[team.ID] {
+			return nil, fmt.Errorf("team %s: duplicated id", team.ID)
+		}
+		ids[team.ID] = true
+		if team.Name == "" {
+			team.Name = team.ID
+		}
+		if team.SprintDays < 0 {
+			return nil, fmt.Errorf("team %s: sprint_days can't be negative", team.ID)
+		}
+		if team.SLE != nil && (team.SLE.Percentile <= 0 || team.SLE.Percentile > 100 || team.SLE.Days <= 0) {
+			return nil, fmt.Errorf("team %s: the sle needs a percentile between 0 and 100 and a positive number of days", team.ID)
+		}
+		if _, err := team.HolidayDates(); err != nil {
+			return nil, fmt.Errorf("team %s: %w", team.ID, err)
+		}
+	}
+	return &Registry{teams: teams}, nil
+}
+
+// Teams returns the configured teams, nil registries have none
+func (r *Registry) Teams() []Team {
+	if r == nil {
+		return []Team{}
+	}
+	return r.teams
+}
+
+// Get returns the team with the ID, or ErrTeamNotFound
+func (r *Registry) Get(id string) (Team, error) {
+	for _, team := range r.Teams() {
+		if team.ID == id {
+			return team, nil
+		}
+	}
+	return Team{}, fmt.Errorf("%w: %s", ErrTeamNotFound, id)
+}
+
+// HolidayDates returns the holidays of the team at midnight of the local time
+func (t Team) HolidayDates() ([]time.Time, error) {
+	dates := []time.Time{}
+	for _, holiday := range t.Holidays {
+		date, err := time.ParseInLocation("2006-01-02", holiday, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("holiday %s is not a YYYY-MM-DD date", holiday)
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+// ProjectList returns the GitLab projects of the team separated by commas, like the projects filter of the dashboard
+func (t Team) ProjectList() string {
+	projects := []string{}
+	for _, project := range t.Projects {
+		projects = append(projects, fmt.Sprint(project))
+	}
+	return strings.Join(projects, ",")
+}
@@ -0,0 +1,65 @@
+package teams
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	registry, err := Parse(strings.NewReader(`[
+		{"id": "core", "name": "Core", "clickup_lists": ["1"], "gitlab_projects": [123, 456], "prefix": "CORE", "holidays": ["2024-12-25"], "sle": {"percentile": 85, "days": 10}},
+		{"id": "prga"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.Teams()) != 2 {
+		t.Fatalf("Cantidad de equipos incorrecta, se esperaba %d pero se obtuvo %d", 2, len(registry.Teams()))
+	}
+
+	team, err := registry.Get("core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.ProjectList() != "123,456" {
+		t.Errorf("Proyectos incorrectos, se esperaba %s pero se obtuvo %s", "123,456", team.ProjectList())
+	}
+	if team.SLE == nil || team.SLE.Percentile != 85 || team.SLE.Days != 10 {
+		t.Errorf("SLE incorrecto, se obtuvo %+v", team.SLE)
+	}
+	prga, _ := registry.Get("prga")
+	if prga.Name != "prga" || prga.SLE != nil {
+		t.Errorf("Equipo incorrecto, se esperaba el ID como nombre y sin SLE pero se obtuvo %+v", prga)
+	}
+
+	if _, err := registry.Get("missing"); !errors.Is(err, ErrTeamNotFound) {
+		t.Errorf("Se esperaba ErrTeamNotFound pero se obtuvo %v", err)
+	}
+}
+
+func TestParseInvalidTeams(t *testing.T) {
+	invalid := []string{
+		`{"id": "core"}`,
+		`[{"name": "Sin ID"}]`,
+		`[{"id": "core"}, {"id": "core"}]`,
+		`[{"id": "core", "holidays": ["25/12/2024"]}]`,
+		`[{"id": "core", "sle": {"percentile": 120, "days": 10}}]`,
+		`[{"id": "core", "sle": {"percentile": 85}}]`,
+	}
+	for _, teams := range invalid {
+		if _, err := Parse(strings.NewReader(teams)); err == nil {
+			t.Errorf("Se esperaba un error para %s", teams)
+		}
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var registry *Registry
+	if len(registry.Teams()) != 0 {
+		t.Errorf("Un registro sin configurar no debería tener equipos")
+	}
+	if _, err := registry.Get("core"); !errors.Is(err, ErrTeamNotFound) {
+		t.Errorf("Se esperaba ErrTeamNotFound pero se obtuvo %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package web
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+)
+
+// The assets are committed to the repository, so builds don't need network access. To add or upgrade one,
+// add it to Vendored without Integrity and run go generate ./web to download it and print its hash.
+//go:generate go run ../cmd/assets
+
+// Asset is a front-end library shipped in the static folder, so browsers don't need access to a CDN
+type Asset struct {
+	// Path is the location of the file inside the static folder
+	Path string
+	// URL is where the file is downloaded from when it is missing
+	URL string
+	// Integrity is the Subresource Integrity hash of the file, empty when it isn't pinned yet
+	Integrity string
+}
+
+// Hash returns the Subresource Integrity hash of the content, as set in Integrity
+func Hash(content []byte) string {
+	sum := sha512.Sum384(content)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Verify checks that the content matches the pinned Integrity of the asset
+func (a Asset) Verify(content []byte) error {
+	if a.Integrity == "" {
+		return fmt.Errorf("%s isn't pinned, set its Integrity to %s", a.Path, Hash(content))
+	}
+	if integrity := Hash(content); integrity != a.Integrity {
+		return fmt.Errorf("integrity of %s doesn't match: expected %s but got %s", a.Path, a.Integrity, integrity)
+	}
+	return nil
+}
+
+// CheckVendored checks that every vendored asset is in the static folder and matches its integrity
+func CheckVendored(static fs.FS) error {
+	for _, asset := range Vendored {
+		content, err := fs.ReadFile(static, asset.Path)
+		if err != nil {
+			return fmt.Errorf("missing static file %s: %w", asset.Path, err)
+		}
+		if err := asset.Verify(content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Vendored are the libraries used by the dashboard templates, committed under the static folder
+var Vendored = []Asset{
+	{
+		Path:      "lib/bootstrap-5.3.2/bootstrap.min.css",
+		URL:       "https://cdn.jsdelivr.net/npm/bootstrap@5.3.2/dist/css/bootstrap.min.css",
+		Integrity: "sha384-T3c6CoIi6uLrA9TneNEoa7RxnatzjcDSCmG1MXxSR1GAsXEV/Dwwykc2MPK8M2HN",
+	},
+	{
+		// The bundle includes Popper
+		Path:      "lib/bootstrap-5.3.2/bootstrap.bundle.min.js",
+		URL:       "https://cdn.jsdelivr.net/npm/bootstrap@5.3.2/dist/js/bootstrap.bundle.min.js",
+		Integrity: "sha384-C6RzsynM9kWDrMNeT87bh95OGNyZPhcTNXj1NW7RuBCsyN/o0jlpcV8Qyq46cDfL",
+	},
+	{
+		Path:      "lib/jquery-3.6.1/jquery.min.js",
+		URL:       "https://code.jquery.com/jquery-3.6.1.min.js",
+		Integrity: "sha384-JaYDaDOsCI9GJRG1Hl8b8dCpm5Sf+LWQUddl2riRDuKnw2Gp3qKbhIPC48ZxFr+U",
+	},
+	{
+		Path: "lib/chartjs-4.4.0/chart.umd.js",
+		URL:  "https://cdn.jsdelivr.net/npm/chart.js@4.4.0/dist/chart.umd.js",
+	},
+}
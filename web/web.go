@@ -0,0 +1,27 @@
+/*
+Package web contains the templates and static files of the dashboard, embedded into the binary so the service
+doesn't depend on the directory it runs from.
+
+In dev mode the files are read from the web folder on disk instead, so changes are seen without rebuilding.
+*/
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+//go:embed templates static
+var embedded embed.FS
+
+// DevDir is the folder the files are read from in dev mode, relative to the root of the repository
+const DevDir = "web"
+
+// Files returns the templates and static folders, the embedded ones or the ones of DevDir when dev is set
+func Files(dev bool) fs.FS {
+	if dev {
+		return os.DirFS(DevDir)
+	}
+	return embedded
+}